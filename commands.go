@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"datagenerator/config"
+	"datagenerator/generator"
+	"datagenerator/generator/dataset"
+	"datagenerator/generator/elastic"
+	banking "datagenerator/generator/postgres"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/timeline"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{name: "schema", summary: "Create the banking schema and ETL metadata tables in Postgres", run: runSchema},
+	{name: "seed", summary: "Seed tenants, customers, accounts and transactions into the banking schema", run: runSeed},
+	{name: "mutate", summary: "Apply an insert/update/delete workload to the seeded banking schema", run: runMutate},
+	{name: "activity", summary: "Generate rows for a dataset table (default user_activity_log) into a single target", run: runActivity},
+	{name: "es-schema", summary: "Create the Elasticsearch analytics indices", run: runESSchema},
+	{name: "render", summary: "Print a dataset's DDL, MongoDB indexes or Elasticsearch mapping", run: runRender},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: datagenerator %s [flags]\n\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args and rejects stray positional arguments
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+// datasetFlag adds --dataset, naming a built-in dataset or a YAML spec file
+func datasetFlag(fs *flag.FlagSet, def string) *string {
+	return fs.String("dataset", def, "built-in dataset ("+strings.Join(dataset.Builtins(), ", ")+") or path to a YAML spec")
+}
+
+func runSchema(args []string) error {
+	fs := newFlagSet("schema")
+	connFlags := config.RegisterFlags(fs)
+	datasetName := datasetFlag(fs, "banking")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	ds, err := dataset.Load(*datasetName)
+	if err != nil {
+		return err
+	}
+	conn, err := connFlags.Resolve(config.Banking)
+	if err != nil {
+		return err
+	}
+	banking.CreateAirportDemoPostgresSchema(conn, ds)
+	return nil
+}
+
+func runSeed(args []string) error {
+	cfg := banking.DefaultSeedConfig()
+	fs := newFlagSet("seed")
+	connFlags := config.RegisterFlags(fs)
+	fs.IntVar(&cfg.Tenants, "tenants", cfg.Tenants, "number of tenants to ensure exist")
+	fs.IntVar(&cfg.CustomersPerTenant, "customers", cfg.CustomersPerTenant, "customers per tenant")
+	fs.IntVar(&cfg.AccountsPerCustomer, "accounts", cfg.AccountsPerCustomer, "accounts per customer")
+	fs.Float64Var(&cfg.MailingAddressRatio, "mailing-addresses", cfg.MailingAddressRatio, "share of new customers with a separate mailing address")
+	fs.IntVar(&cfg.KYCPerCustomer, "kyc-checks", cfg.KYCPerCustomer, "KYC checks per new customer")
+	fs.Float64Var(&cfg.KYCPendingRatio, "kyc-pending", cfg.KYCPendingRatio, "share of KYC checks still pending")
+	fs.Float64Var(&cfg.KYCFailedRatio, "kyc-failed", cfg.KYCFailedRatio, "share of KYC checks that failed")
+	fs.IntVar(&cfg.DocumentsPerCustomer, "documents", cfg.DocumentsPerCustomer, "uploaded documents per new customer")
+	fs.Float64Var(&cfg.CardRatio, "cards", cfg.CardRatio, "share of accounts with a card")
+	fs.StringVar(&cfg.CardHashKey, "card-hash-key", os.Getenv("DATAGEN_CARD_HASH_KEY"), "HMAC-SHA256 key for card number hashes (default $DATAGEN_CARD_HASH_KEY, plain SHA-256 if empty)")
+	fs.StringVar(&cfg.PANFile, "pan-file", "", "CSV file to append generated card numbers to; they are never written to the database")
+	fs.IntVar(&cfg.CardTransactions, "card-transactions", cfg.CardTransactions, "card transactions to create per run")
+	fs.Float64Var(&cfg.ForeignCardRatio, "foreign-card-share", cfg.ForeignCardRatio, "share of card transactions at merchants abroad")
+	fs.Float64Var(&cfg.LoanRatio, "loans", cfg.LoanRatio, "share of customers with a loan")
+	fs.Float64Var(&cfg.LatePaymentRatio, "late-payments", cfg.LatePaymentRatio, "share of loan installments paid late, with a penalty")
+	fs.Float64Var(&cfg.PartialPaymentRatio, "partial-payments", cfg.PartialPaymentRatio, "share of loan installments paid only in part")
+	fs.BoolVar(&cfg.Audit, "audit", cfg.Audit, "write an audit_logs row for every row the seeder creates or updates")
+	fs.Float64Var(&cfg.FraudRatio, "fraud", cfg.FraudRatio, "share of transactions and card transactions injected by labelled fraud scenarios, each with a fraud alert")
+	fs.BoolVar(&cfg.LabelAll, "label-all", cfg.LabelAll, "label legit transactions with their fraud_label and origin too, five transaction_metadata rows each (default: only injected ones; no label means legit)")
+	fs.IntVar(&cfg.TransactionsToCreate, "transactions", cfg.TransactionsToCreate, "transactions to create per run")
+	fs.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "rows per COPY or multi-VALUES insert")
+	fs.BoolVar(&cfg.Copy, "copy", cfg.Copy, "load customers, accounts and transactions with COPY (--copy=false uses multi-VALUES inserts)")
+	fs.IntVar(&cfg.Generators, "generators", cfg.Generators, "goroutines generating transactions")
+	fs.IntVar(&cfg.Writers, "writers", cfg.Writers, "goroutines writing transaction batches, each on its own connection")
+	fs.Float64Var(&cfg.AccountSkew, "account-skew", cfg.AccountSkew, "Zipf exponent for picking transaction accounts, above 1 (0 picks uniformly)")
+	fs.Float64Var(&cfg.Overdraft, "overdraft", cfg.Overdraft, "how far below zero a transaction may take an account (0 declines anything that would overdraw)")
+	fs.StringVar(&cfg.Weights, "weights", "", "YAML file of category weights (default: built-in generator/postgres/weights.yaml)")
+	runs := fs.Int("runs", 1, "number of consecutive seed runs")
+	seedFlags := rng.RegisterFlags(fs)
+	timeFlags := timeline.RegisterFlags(fs, "6 months before --now", cfg.Time.Shape)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *runs <= 0 {
+		return fmt.Errorf("runs must be positive, got %d", *runs)
+	}
+	conn, err := connFlags.Resolve(config.Banking)
+	if err != nil {
+		return err
+	}
+	seed, now, err := seedFlags.Resolve()
+	if err != nil {
+		return err
+	}
+	if cfg.Time, err = timeFlags.Resolve(banking.DefaultTimeModel(now)); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	// Each run draws from its own fork so consecutive runs add new rows
+	// while the whole sequence stays reproducible
+	root := rng.New(seed, now)
+	for i := range *runs {
+		runCfg := cfg
+		runCfg.Seed = root.Fork(int64(i)).Seed
+		runCfg.RootSeed = seed
+		runCfg.Now = now
+		banking.PerformSeed(conn, runCfg)
+	}
+	return nil
+}
+
+func runMutate(args []string) error {
+	cfg := banking.DefaultMutateConfig()
+	fs := newFlagSet("mutate")
+	connFlags := config.RegisterFlags(fs)
+	fs.IntVar(&cfg.Operations, "operations", cfg.Operations, "inserts, updates and deletes to apply")
+	fs.Var(&cfg.Mix, "mix", "insert/update/delete weights")
+	fs.IntVar(&cfg.BatchSize, "batch-size", cfg.BatchSize, "operations per transaction")
+	fs.Float64Var(&cfg.AccountSkew, "account-skew", cfg.AccountSkew, "Zipf exponent for picking accounts, above 1 (0 picks uniformly)")
+	fs.Float64Var(&cfg.Overdraft, "overdraft", cfg.Overdraft, "how far below zero a posting may take an account (0 fails anything that would overdraw)")
+	fs.StringVar(&cfg.Weights, "weights", "", "YAML file of category weights (default: built-in generator/postgres/weights.yaml)")
+	fs.BoolVar(&cfg.Audit, "audit", cfg.Audit, "write an audit_logs row for every update")
+	seedFlags := rng.RegisterFlags(fs)
+	timeFlags := timeline.RegisterFlags(fs, "6 months before --now", cfg.Time.Shape)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	conn, err := connFlags.Resolve(config.Banking)
+	if err != nil {
+		return err
+	}
+	if cfg.Seed, cfg.Now, err = seedFlags.Resolve(); err != nil {
+		return err
+	}
+	if cfg.Time, err = timeFlags.Resolve(banking.DefaultTimeModel(cfg.Now)); err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	banking.PerformMutations(conn, cfg)
+	return nil
+}
+
+func runActivity(args []string) error {
+	opts := generator.DefaultActivityOptions()
+	fs := newFlagSet("activity")
+	connFlags := config.RegisterFlags(fs)
+	target := fs.String("target", "", "target backend: "+strings.Join(generator.TargetNames(), ", "))
+	datasetName := datasetFlag(fs, generator.DefaultDataset)
+	tableName := fs.String("table", generator.DefaultTable, "table of the dataset to generate")
+	fs.IntVar(&opts.Records, "records", opts.Records, "number of rows to insert")
+	fs.IntVar(&opts.BatchSize, "batch-size", opts.BatchSize, "rows per transaction / bulk request")
+	fs.IntVar(&opts.Generators, "generators", opts.Generators, "goroutines generating rows")
+	fs.IntVar(&opts.Writers, "writers", opts.Writers, "goroutines writing batches, each with its own connection")
+	fs.Float64Var(&opts.Stream.Rate, "rate", 0, "stream this many records/second instead of inserting --records (0 disables streaming)")
+	fs.DurationVar(&opts.Stream.Duration, "duration", 0, "how long to stream (default: until interrupted)")
+	fs.DurationVar(&opts.Stream.RampUp, "ramp-up", 0, "ramp the stream rate up from zero over this long")
+	fs.DurationVar(&opts.Stream.BurstEvery, "burst-every", 0, "start a burst at this interval while streaming")
+	fs.DurationVar(&opts.Stream.BurstFor, "burst-for", 0, "length of each burst")
+	fs.Float64Var(&opts.Stream.BurstFactor, "burst-factor", 1, "rate multiplier during a burst")
+	fs.Var(&opts.Mix, "mix", "insert/update/delete weights, e.g. 70/25/5 (updates and deletes hit existing rows)")
+	loader := fs.String("loader", string(opts.Loader), "SQL ingestion path: "+strings.Join(generator.LoaderNames(), ", ")+
+		fmt.Sprintf(" (auto bulk loads runs of %d+ rows)", generator.BulkThreshold))
+	seedFlags := rng.RegisterFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *target == "" {
+		return fmt.Errorf("--target is required (valid: %s)", strings.Join(generator.TargetNames(), ", "))
+	}
+	if _, ok := generator.Targets[*target]; !ok {
+		return fmt.Errorf("unknown target %q (valid: %s)", *target, strings.Join(generator.TargetNames(), ", "))
+	}
+	ds, err := dataset.Load(*datasetName)
+	if err != nil {
+		return err
+	}
+	if opts.Table, err = ds.Lookup(*tableName); err != nil {
+		return err
+	}
+	conn, err := connFlags.Resolve(*target)
+	if err != nil {
+		return err
+	}
+	if opts.Seed, opts.Now, err = seedFlags.Resolve(); err != nil {
+		return err
+	}
+	opts.Loader = generator.Loader(*loader)
+	return generator.RunActivity(context.Background(), *target, conn, opts)
+}
+
+func runESSchema(args []string) error {
+	fs := newFlagSet("es-schema")
+	connFlags := config.RegisterFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	conn, err := connFlags.Resolve(config.Elasticsearch)
+	if err != nil {
+		return err
+	}
+	return elastic.CreateElasticsearchSchema(conn)
+}
+
+func runRender(args []string) error {
+	fs := newFlagSet("render")
+	datasetName := datasetFlag(fs, generator.DefaultDataset)
+	tableName := fs.String("table", "", "render only this table (default: all tables)")
+	dialect := fs.String("dialect", "postgres", "output format: "+strings.Join(append(dataset.DialectNames(), "mongodb", "elasticsearch"), ", "))
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	ds, err := dataset.Load(*datasetName)
+	if err != nil {
+		return err
+	}
+	tables := ds.Tables
+	if *tableName != "" {
+		table, err := ds.Lookup(*tableName)
+		if err != nil {
+			return err
+		}
+		tables = []*dataset.Table{table}
+	}
+
+	switch *dialect {
+	case "mongodb":
+		for _, table := range tables {
+			for _, index := range dataset.MongoIndexes(table) {
+				keys := make([]string, len(index.Columns))
+				for i, column := range index.Columns {
+					keys[i] = column + ": 1"
+				}
+				fmt.Printf("db.%s.createIndex({%s}, {name: %q, unique: %t, sparse: %t})\n",
+					table.Name, strings.Join(keys, ", "), index.Name, index.Unique, index.Sparse)
+			}
+		}
+	case "elasticsearch":
+		for _, table := range tables {
+			mapping, err := json.MarshalIndent(dataset.ESMapping(table), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("PUT /%s\n{\"mappings\": %s}\n\n", dataset.ESIndex(table), mapping)
+		}
+	default:
+		d, ok := dataset.Dialects[*dialect]
+		if !ok {
+			return fmt.Errorf("unknown dialect %q", *dialect)
+		}
+		for _, table := range tables {
+			for _, stmt := range d.CreateTable(table) {
+				fmt.Println(strings.TrimSuffix(stmt.SQL, ";") + ";")
+			}
+			fmt.Println()
+		}
+	}
+	return nil
+}
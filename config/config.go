@@ -0,0 +1,202 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Backend names used as keys inside a profile
+const (
+	Postgres      = "postgres"      // user_activity_log on source_data_db
+	Banking       = "banking"       // banking schema on banking_db
+	MySQL         = "mysql"         // user_activity_log on MySQL
+	MariaDB       = "mariadb"       // user_activity_log on MariaDB
+	MSSQL         = "mssql"         // user_activity_log on SQL Server
+	Oracle        = "oracle"        // user_activity_log on Oracle
+	Redis         = "redis"         // user_activity hashes
+	MongoDB       = "mongodb"       // user_activity_log collection
+	Elasticsearch = "elasticsearch" // activity and analytics indices
+)
+
+const (
+	DefaultProfile = "local"
+	DefaultFile    = "datagenerator.yaml" // Picked up from the working directory when present
+)
+
+// Connection holds everything needed to reach one backend. Not every field
+// applies to every backend: MongoDB uses URI, Elasticsearch uses Addresses,
+// Redis uses Database as the numeric DB index.
+type Connection struct {
+	Host      string   `yaml:"host,omitempty"`
+	Port      int      `yaml:"port,omitempty"`
+	User      string   `yaml:"user,omitempty"`
+	Password  string   `yaml:"password,omitempty"`
+	Database  string   `yaml:"database,omitempty"`
+	Schema    string   `yaml:"schema,omitempty"`
+	SSLMode   string   `yaml:"sslmode,omitempty"`
+	URI       string   `yaml:"uri,omitempty"`
+	Addresses []string `yaml:"addresses,omitempty"`
+}
+
+// Profile is a named set of backend connections, e.g. "local" or "staging"
+type Profile map[string]Connection
+
+// File is the on-disk layout of a connection configuration file
+type File struct {
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Defaults returns the built-in "local" profile, matching the docker-compose
+// style setup the generators were originally written against
+func Defaults() Profile {
+	return Profile{
+		Postgres: {
+			Host: "localhost", Port: 5432, User: "postgres", Password: "postgres",
+			Database: "source_data_db", Schema: "source_schema", SSLMode: "disable",
+		},
+		Banking: {
+			Host: "localhost", Port: 5432, User: "postgres", Password: "postgres",
+			Database: "banking_db", SSLMode: "disable",
+		},
+		MySQL: {
+			Host: "localhost", Port: 3306, User: "root", Password: "mysql",
+			Database: "source_data_db",
+		},
+		MariaDB: {
+			Host: "localhost", Port: 3307, User: "root", Password: "mariadb",
+			Database: "source_data_db",
+		},
+		MSSQL: {
+			Host: "localhost", Port: 1433, User: "sa", Password: "Mssql@123",
+			Database: "source_data_db",
+		},
+		Oracle: {
+			Host: "localhost", Port: 1521, User: "pdbadmin", Password: "oracledb",
+			Database: "source_data_db",
+		},
+		Redis: {
+			Host: "localhost", Port: 6379, User: "default", Password: "redisdb",
+			Database: "0",
+		},
+		MongoDB: {
+			URI: "mongodb://localhost:27017", Database: "source_data_db",
+		},
+		Elasticsearch: {
+			Addresses: []string{"http://localhost:9200"},
+		},
+	}
+}
+
+// Backends returns the known backend names in a stable order
+func Backends() []string {
+	names := make([]string, 0, len(Defaults()))
+	for name := range Defaults() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load resolves the named profile. Values are layered, later wins:
+// built-in defaults, the profile from path (if any), then DATAGEN_* environment
+// variables. An empty path falls back to DATAGEN_CONFIG and then DefaultFile.
+func Load(path, profile string) (Profile, error) {
+	if path == "" {
+		path = os.Getenv("DATAGEN_CONFIG")
+	}
+	if profile == "" {
+		profile = os.Getenv("DATAGEN_PROFILE")
+	}
+
+	var file File
+	if path == "" {
+		if _, err := os.Stat(DefaultFile); err == nil {
+			path = DefaultFile
+		}
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("parse config %s: %w", path, err)
+		}
+	}
+
+	if profile == "" {
+		profile = file.DefaultProfile
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+
+	resolved := Defaults()
+	overrides, ok := file.Profiles[profile]
+	if !ok && profile != DefaultProfile {
+		if path == "" {
+			return nil, fmt.Errorf("profile %q requested but no config file found", profile)
+		}
+		return nil, fmt.Errorf("profile %q not found in %s", profile, path)
+	}
+	for backend, conn := range overrides {
+		if _, known := resolved[backend]; !known {
+			return nil, fmt.Errorf("profile %q: unknown backend %q (valid: %v)", profile, backend, Backends())
+		}
+		resolved[backend] = resolved[backend].merge(conn)
+	}
+
+	for backend, conn := range resolved {
+		conn, err := conn.applyEnv(backend)
+		if err != nil {
+			return nil, err
+		}
+		resolved[backend] = conn
+	}
+	return resolved, nil
+}
+
+// Connection returns the resolved connection for backend
+func (p Profile) Connection(backend string) (Connection, error) {
+	conn, ok := p[backend]
+	if !ok {
+		return Connection{}, fmt.Errorf("unknown backend %q (valid: %v)", backend, Backends())
+	}
+	return conn, nil
+}
+
+// merge overlays the non-zero fields of o onto c
+func (c Connection) merge(o Connection) Connection {
+	if o.Host != "" {
+		c.Host = o.Host
+	}
+	if o.Port != 0 {
+		c.Port = o.Port
+	}
+	if o.User != "" {
+		c.User = o.User
+	}
+	if o.Password != "" {
+		c.Password = o.Password
+	}
+	if o.Database != "" {
+		c.Database = o.Database
+	}
+	if o.Schema != "" {
+		c.Schema = o.Schema
+	}
+	if o.SSLMode != "" {
+		c.SSLMode = o.SSLMode
+	}
+	if o.URI != "" {
+		c.URI = o.URI
+	}
+	if len(o.Addresses) > 0 {
+		c.Addresses = o.Addresses
+	}
+	return c
+}
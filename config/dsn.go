@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/godror/godror/dsn"
+)
+
+// PostgresDSN builds a libpq keyword/value connection string, quoting every
+// value so spaces, quotes and backslashes in passwords survive
+func (c Connection) PostgresDSN() string {
+	sslMode := c.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		pgQuote(c.Host), c.Port, pgQuote(c.User), pgQuote(c.Password), pgQuote(c.Database), pgQuote(sslMode))
+	if c.Schema != "" {
+		dsn += " search_path=" + pgQuote(c.Schema)
+	}
+	return dsn
+}
+
+// pgQuote single-quotes a keyword/value connection string value, escaping
+// backslashes and quotes
+func pgQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// MySQLDSN builds a go-sql-driver/mysql DSN (also used for MariaDB)
+func (c Connection) MySQLDSN() string {
+	m := mysql.NewConfig()
+	m.User, m.Passwd = c.User, c.Password
+	m.Net, m.Addr = "tcp", c.Addr()
+	m.DBName = c.Database
+	return m.FormatDSN()
+}
+
+// MSSQLDSN builds a go-mssqldb sqlserver:// URL
+func (c Connection) MSSQLDSN() string {
+	u := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(c.User, c.Password),
+		Host:     c.Addr(),
+		RawQuery: url.Values{"database": {c.Database}}.Encode(),
+	}
+	return u.String()
+}
+
+// OracleDSN builds a godror connection string for the easy-connect address
+// host:port/service, with godror's default pool settings
+func (c Connection) OracleDSN() string {
+	p, _ := dsn.Parse("") // the defaults; parsing nothing cannot fail
+	p.Username, p.Password = c.User, dsn.NewPassword(c.Password)
+	p.ConnectString = c.Addr() + "/" + c.Database
+	return p.StringWithPassword()
+}
+
+// Addr returns host:port
+func (c Connection) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// RedisDB returns Database parsed as a Redis DB index
+func (c Connection) RedisDB() (int, error) {
+	if c.Database == "" {
+		return 0, nil
+	}
+	db, err := strconv.Atoi(c.Database)
+	if err != nil {
+		return 0, fmt.Errorf("redis database must be a numeric index, got %q", c.Database)
+	}
+	return db, nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/denisenkom/go-mssqldb/msdsn"
+	"github.com/go-sql-driver/mysql"
+	"github.com/godror/godror/dsn"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// awkward are credentials with every character the DSN formats treat as
+// syntax
+var awkward = []Connection{
+	{Host: "localhost", Port: 5432, User: "app", Password: "secret", Database: "db"},
+	{Host: "db.internal", Port: 1433, User: "o'brien", Password: `p a\ss'w=rd`, Database: "data base"},
+	{Host: "10.0.0.7", Port: 3306, User: "user@corp", Password: "p@ss/w:rd?#1", Database: "app"},
+	{Host: "sql", Port: 1521, User: "svc", Password: `semi;colon;"quoted" {braced}`, Database: "FREEPDB1"},
+	{Host: "::1", Port: 5432, User: "u", Password: "%20&key=value", Database: "d"},
+}
+
+func TestPostgresDSN(t *testing.T) {
+	for _, c := range awkward {
+		c.Schema = "my schema"
+		cfg, err := pgconn.ParseConfig(c.PostgresDSN())
+		if err != nil {
+			t.Fatalf("%s: %v", c.PostgresDSN(), err)
+		}
+		if cfg.Host != c.Host || int(cfg.Port) != c.Port || cfg.User != c.User ||
+			cfg.Password != c.Password || cfg.Database != c.Database || cfg.RuntimeParams["search_path"] != c.Schema {
+			t.Errorf("%s parsed as host %q port %d user %q password %q database %q search_path %q",
+				c.PostgresDSN(), cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.RuntimeParams["search_path"])
+		}
+	}
+}
+
+func TestMySQLDSN(t *testing.T) {
+	for _, c := range awkward {
+		cfg, err := mysql.ParseDSN(c.MySQLDSN())
+		if err != nil {
+			t.Fatalf("%s: %v", c.MySQLDSN(), err)
+		}
+		if cfg.Net != "tcp" || cfg.Addr != c.Addr() || cfg.User != c.User || cfg.Passwd != c.Password || cfg.DBName != c.Database {
+			t.Errorf("%s parsed as %s %q user %q password %q database %q",
+				c.MySQLDSN(), cfg.Net, cfg.Addr, cfg.User, cfg.Passwd, cfg.DBName)
+		}
+	}
+}
+
+func TestMSSQLDSN(t *testing.T) {
+	for _, c := range awkward {
+		cfg, _, err := msdsn.Parse(c.MSSQLDSN())
+		if err != nil {
+			t.Fatalf("%s: %v", c.MSSQLDSN(), err)
+		}
+		if cfg.Host != c.Host || int(cfg.Port) != c.Port || cfg.User != c.User ||
+			cfg.Password != c.Password || cfg.Database != c.Database {
+			t.Errorf("%s parsed as host %q port %d user %q password %q database %q",
+				c.MSSQLDSN(), cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database)
+		}
+	}
+}
+
+func TestOracleDSN(t *testing.T) {
+	for _, c := range awkward {
+		p, err := dsn.Parse(c.OracleDSN())
+		if err != nil {
+			t.Fatalf("%s: %v", c.OracleDSN(), err)
+		}
+		if want := c.Addr() + "/" + c.Database; p.Username != c.User || p.Password.Secret() != c.Password || p.ConnectString != want {
+			t.Errorf("%s parsed as user %q password %q connect string %q, want %q",
+				c.OracleDSN(), p.Username, p.Password.Secret(), p.ConnectString, want)
+		}
+	}
+}
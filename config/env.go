@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envName returns the environment variable for a backend field,
+// e.g. envName("mysql", "host") == "DATAGEN_MYSQL_HOST"
+func envName(backend, field string) string {
+	return "DATAGEN_" + strings.ToUpper(backend) + "_" + strings.ToUpper(field)
+}
+
+// applyEnv overlays DATAGEN_<BACKEND>_<FIELD> variables onto c
+func (c Connection) applyEnv(backend string) (Connection, error) {
+	var env Connection
+	env.Host = os.Getenv(envName(backend, "host"))
+	if port := os.Getenv(envName(backend, "port")); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return c, fmt.Errorf("%s: invalid port %q", envName(backend, "port"), port)
+		}
+		env.Port = p
+	}
+	env.User = os.Getenv(envName(backend, "user"))
+	env.Password = os.Getenv(envName(backend, "password"))
+	env.Database = os.Getenv(envName(backend, "database"))
+	env.Schema = os.Getenv(envName(backend, "schema"))
+	env.SSLMode = os.Getenv(envName(backend, "sslmode"))
+	env.URI = os.Getenv(envName(backend, "uri"))
+	if addrs := os.Getenv(envName(backend, "addresses")); addrs != "" {
+		env.Addresses = strings.Split(addrs, ",")
+	}
+	return c.merge(env), nil
+}
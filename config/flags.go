@@ -0,0 +1,49 @@
+package config
+
+import (
+	"flag"
+	"strings"
+)
+
+// Flags are the connection flags shared by every CLI command. Connection
+// overrides only apply to the backend the command talks to.
+type Flags struct {
+	File    string
+	Profile string
+
+	overrides Connection
+	addresses string
+}
+
+// RegisterFlags adds the connection flags to fs
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.File, "config", "", "connection config file (default $DATAGEN_CONFIG or ./"+DefaultFile+")")
+	fs.StringVar(&f.Profile, "profile", "", "connection profile name (default $DATAGEN_PROFILE or "+DefaultProfile+")")
+	fs.StringVar(&f.overrides.Host, "host", "", "override backend host")
+	fs.IntVar(&f.overrides.Port, "port", 0, "override backend port")
+	fs.StringVar(&f.overrides.User, "user", "", "override backend user")
+	fs.StringVar(&f.overrides.Password, "password", "", "override backend password")
+	fs.StringVar(&f.overrides.Database, "database", "", "override backend database (Redis: DB index)")
+	fs.StringVar(&f.overrides.Schema, "schema", "", "override backend schema / search_path")
+	fs.StringVar(&f.overrides.URI, "uri", "", "override backend URI (MongoDB)")
+	fs.StringVar(&f.addresses, "addresses", "", "override comma-separated addresses (Elasticsearch)")
+	return f
+}
+
+// Resolve loads the selected profile and applies flag overrides to backend
+func (f *Flags) Resolve(backend string) (Connection, error) {
+	profile, err := Load(f.File, f.Profile)
+	if err != nil {
+		return Connection{}, err
+	}
+	conn, err := profile.Connection(backend)
+	if err != nil {
+		return Connection{}, err
+	}
+	overrides := f.overrides
+	if f.addresses != "" {
+		overrides.Addresses = strings.Split(f.addresses, ",")
+	}
+	return conn.merge(overrides), nil
+}
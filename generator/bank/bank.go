@@ -0,0 +1,271 @@
+// Package bank numbers accounts the way banks of each country do, from an
+// embedded registry of banks: IBANs with their mod-97 check digits where
+// the country uses them and domestic formats elsewhere, such as an ABA
+// routing number followed by the account in the US.
+package bank
+
+import (
+	_ "embed"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"datagenerator/generator/geo"
+	"datagenerator/generator/person"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/weighted"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed banks.yaml
+var banksYAML []byte
+
+// layout is how a country numbers accounts: the length of its bank and
+// branch codes, the number of account digits and how the three make the
+// account number
+type layout struct {
+	code, branch, digits int
+	format               func(code, branch, account string) string
+}
+
+var layouts = map[string]layout{
+	// ABA routing number, account
+	"US": {9, 0, 12, func(code, _, account string) string { return code + account }},
+	// Transit, institution, account
+	"CA": {3, 5, 7, func(code, branch, account string) string { return branch + code + account }},
+	// IBAN of bank, sort code, account
+	"GB": {4, 6, 8, func(code, branch, account string) string { return IBAN("GB", code+branch+account) }},
+	// IBAN of Bankleitzahl, account
+	"DE": {8, 0, 10, func(code, _, account string) string { return IBAN("DE", code+account) }},
+	// IBAN of bank, branch, account and RIB key
+	"FR": {5, 5, 11, func(code, branch, account string) string {
+		rib := code + branch + account
+		return IBAN("FR", fmt.Sprintf("%s%02d", rib, 97-mod97(rib+"00")))
+	}},
+	// Bank, branch, account
+	"JP": {4, 3, 7, func(code, branch, account string) string { return code + branch + account }},
+	// BSB, account
+	"AU": {2, 4, 9, func(code, branch, account string) string { return code + branch + account }},
+	// IBAN of ISPB, branch, account, a checking account (C) of its first holder (1)
+	"BR": {8, 5, 10, func(code, branch, account string) string { return IBAN("BR", code+branch+account+"C1") }},
+	// IFSC, account
+	"IN": {4, 7, 12, func(code, branch, account string) string { return code + branch + account }},
+	// Bank, account
+	"CN": {3, 0, 16, func(code, _, account string) string { return code + account }},
+	// Bank, branch, account
+	"SG": {4, 3, 10, func(code, branch, account string) string { return code + branch + account }},
+}
+
+// Bank is a bank of the registry. It is read-only, so one Bank can serve
+// every goroutine.
+type Bank struct {
+	Name    string
+	Code    string // national identifier, see banks.yaml
+	Country string // ISO 3166-1 alpha-2
+
+	branch string // pattern of branch codes
+	layout layout
+}
+
+// country is the banks of one country, in registry order
+type country struct {
+	banks  []*Bank
+	choice *weighted.Choice[*Bank]
+}
+
+type bankSpec struct {
+	Name   string  `yaml:"name"`
+	Code   string  `yaml:"code"`
+	Branch string  `yaml:"branch"`
+	Weight float64 `yaml:"weight"`
+}
+
+var registry = mustLoad()
+
+func mustLoad() map[string]*country {
+	var spec map[string][]bankSpec
+	if err := yaml.Unmarshal(banksYAML, &spec); err != nil {
+		panic("bank: invalid banks.yaml: " + err.Error())
+	}
+	table := make(map[string]*country, len(spec))
+	for code, s := range spec {
+		c, err := compile(code, s)
+		if err != nil {
+			panic(fmt.Sprintf("bank: banks.yaml: %s: %v", code, err))
+		}
+		table[code] = c
+	}
+	return table
+}
+
+func compile(code string, specs []bankSpec) (*country, error) {
+	cc, err := geo.Lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := layouts[cc.Code]
+	if !ok {
+		return nil, fmt.Errorf("no account number layout")
+	}
+	c := &country{}
+	weights := make([]float64, len(specs))
+	for i, s := range specs {
+		if len(s.Code) != l.code || len(s.Branch) != l.branch {
+			return nil, fmt.Errorf("%s: want a %d character code and %d character branch", s.Name, l.code, l.branch)
+		}
+		if cc.Code == "US" && !ValidABA(s.Code) {
+			return nil, fmt.Errorf("%s: invalid routing number %s", s.Name, s.Code)
+		}
+		c.banks = append(c.banks, &Bank{Name: s.Name, Code: s.Code, Country: cc.Code, branch: s.Branch, layout: l})
+		weights[i] = s.Weight
+	}
+	if c.choice, err = weighted.New(c.banks, weights); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func lookup(code string) (*country, error) {
+	cc, err := geo.Lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := registry[cc.Code]
+	if !ok {
+		return nil, fmt.Errorf("no banks for country %s", cc.Code)
+	}
+	return c, nil
+}
+
+// In lists the banks of a country by its alpha-2 or alpha-3 code, in the
+// same order on every call
+func In(code string) ([]*Bank, error) {
+	c, err := lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	return c.banks, nil
+}
+
+// Pick draws a bank of a country by market share
+func Pick(src *rng.Source, code string) (*Bank, error) {
+	c, err := lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	return c.choice.Pick(src), nil
+}
+
+// Codes lists the alpha-2 codes with banks
+func Codes() []string {
+	codes := make([]string, 0, len(registry))
+	for code := range registry {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// spread scatters serials over the account digits. Being coprime to 10, it
+// maps 0..10^n-1 onto itself one to one for any n.
+const spread = 2862933555777941757
+
+// Account numbers the account with serial at a random branch of the bank.
+// Distinct serials below 10^digits of the country get distinct numbers.
+func (b *Bank) Account(src *rng.Source, serial int64) (string, error) {
+	size := pow10(b.layout.digits)
+	if serial < 0 || uint64(serial) >= size {
+		return "", fmt.Errorf("account serial %d does not fit the %d-digit account numbers of %s",
+			serial, b.layout.digits, b.Country)
+	}
+	// Multiplying alone leaves the last digits of neighbouring serials
+	// alike; reversing the digits between two rounds mixes them all
+	n := scatter(reverse(scatter(uint64(serial), size), b.layout.digits), size)
+	return b.number(src, fmt.Sprintf("%0*d", b.layout.digits, n)), nil
+}
+
+// scatter maps n below size, a power of 10, one to one onto 0..size-1
+func scatter(n, size uint64) uint64 {
+	hi, lo := bits.Mul64(n, spread)
+	_, r := bits.Div64(hi%size, lo, size)
+	return r
+}
+
+// reverse reads n, zero-padded to digits decimal digits, backwards
+func reverse(n uint64, digits int) uint64 {
+	var r uint64
+	for range digits {
+		r, n = r*10+n%10, n/10
+	}
+	return r
+}
+
+// RandomAccount numbers an account at a random branch of the bank, with no
+// promise of uniqueness
+func (b *Bank) RandomAccount(src *rng.Source) string {
+	return b.number(src, person.Pattern(src, strings.Repeat("#", b.layout.digits)))
+}
+
+func (b *Bank) number(src *rng.Source, account string) string {
+	return b.layout.format(b.Code, person.Pattern(src, b.branch), account)
+}
+
+func pow10(n int) uint64 {
+	p := uint64(1)
+	for range n {
+		p *= 10
+	}
+	return p
+}
+
+// IBAN prefixes bban with the country code and the check digits that make
+// it a valid IBAN
+func IBAN(country, bban string) string {
+	return fmt.Sprintf("%s%02d%s", country, 98-mod97(bban+country+"00"), bban)
+}
+
+// ValidIBAN reports whether s has the ISO 13616 mod-97 checksum of an IBAN
+func ValidIBAN(s string) bool {
+	if len(s) < 5 || len(s) > 34 {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return mod97(s[4:]+s[:4]) == 1
+}
+
+// mod97 is the remainder by 97 of s read as a number, with letters standing
+// for 10 (A) to 35 (Z)
+func mod97(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 'A' && r <= 'Z' {
+			n = (n*100 + int(r-'A') + 10) % 97
+		} else {
+			n = (n*10 + int(r-'0')) % 97
+		}
+	}
+	return n
+}
+
+// ValidABA reports whether s is nine digits with the 3-7-1 checksum of an
+// ABA routing number
+func ValidABA(s string) bool {
+	if len(s) != 9 {
+		return false
+	}
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+		sum += weights[i%3] * int(r-'0')
+	}
+	return sum%10 == 0
+}
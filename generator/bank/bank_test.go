@@ -0,0 +1,125 @@
+package bank
+
+import (
+	"testing"
+	"time"
+
+	"datagenerator/generator/rng"
+)
+
+func TestIBAN(t *testing.T) {
+	tests := []struct {
+		country, bban, want string
+	}{
+		{"GB", "WEST12345698765432", "GB82WEST12345698765432"},
+		{"DE", "370400440532013000", "DE89370400440532013000"},
+		{"FR", "30006000011234567890189", "FR7630006000011234567890189"},
+		{"BR", "00360305000010009795493P1", "BR9700360305000010009795493P1"},
+	}
+	for _, tt := range tests {
+		if got := IBAN(tt.country, tt.bban); got != tt.want {
+			t.Errorf("IBAN(%s, %s) = %s, want %s", tt.country, tt.bban, got, tt.want)
+		}
+	}
+}
+
+func TestValidIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{"GB82WEST12345698765432", true},
+		{"DE89370400440532013000", true},
+		{"FR7630006000011234567890189", true},
+		{"GB82WEST12345698765433", false},
+		{"DE88370400440532013000", false},
+		{"gb82west12345698765432", false},
+		{"GB82 WEST 1234 5698 7654 32", false},
+		{"GB82", false},
+	}
+	for _, tt := range tests {
+		if got := ValidIBAN(tt.iban); got != tt.want {
+			t.Errorf("ValidIBAN(%q) = %v, want %v", tt.iban, got, tt.want)
+		}
+	}
+}
+
+func TestMod97(t *testing.T) {
+	tests := []struct {
+		s    string
+		want int
+	}{
+		{"0", 0},
+		{"97", 0},
+		{"98", 1},
+		{"A", 10},
+		{"Z", 35},
+		{"WEST12345698765432GB82", 1},
+		{"370400440532013000DE89", 1},
+	}
+	for _, tt := range tests {
+		if got := mod97(tt.s); got != tt.want {
+			t.Errorf("mod97(%s) = %d, want %d", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestFrenchRIBKey(t *testing.T) {
+	got := layouts["FR"].format("30006", "00001", "12345678901")
+	if want := "FR7630006000011234567890189"; got != want {
+		t.Errorf("FR account = %s, want %s", got, want)
+	}
+}
+
+func TestValidABA(t *testing.T) {
+	tests := []struct {
+		routing string
+		want    bool
+	}{
+		{"021000021", true},
+		{"026009593", true},
+		{"011000015", true},
+		{"121000358", true},
+		{"021000022", false},
+		{"02100002", false},
+		{"0210000210", false},
+		{"02100002a", false},
+	}
+	for _, tt := range tests {
+		if got := ValidABA(tt.routing); got != tt.want {
+			t.Errorf("ValidABA(%q) = %v, want %v", tt.routing, got, tt.want)
+		}
+	}
+}
+
+func TestAccount(t *testing.T) {
+	const serials = 20000
+	src := rng.New(1, time.Time{})
+	for _, code := range Codes() {
+		banks, err := In(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := banks[0]
+		seen := make(map[string]int64, serials)
+		for serial := range int64(serials) {
+			account, err := b.Account(src, serial)
+			if err != nil {
+				t.Fatalf("%s: %v", code, err)
+			}
+			if prev, ok := seen[account]; ok {
+				t.Fatalf("%s: serials %d and %d both number %s", code, prev, serial, account)
+			}
+			seen[account] = serial
+			if len(account) > 4 && account[:2] == code && !ValidIBAN(account) {
+				t.Fatalf("%s: serial %d numbers invalid IBAN %s", code, serial, account)
+			}
+			if code == "US" && !ValidABA(account[:9]) {
+				t.Fatalf("US: serial %d numbers %s, want a valid routing number first", serial, account)
+			}
+		}
+		if _, err := b.Account(src, int64(pow10(b.layout.digits))); err == nil {
+			t.Errorf("%s: serial 10^%d succeeded, want an error", code, b.layout.digits)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+// Package card makes synthetic primary account numbers: Luhn-valid, of the
+// brand's length and inside the brand's published test BIN ranges, so they
+// pass format checks without belonging to a real issuer. Stored cards keep
+// only a hash of the number and its last four digits.
+package card
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"datagenerator/generator/rng"
+)
+
+// brand is the numbering of one card network
+type brand struct {
+	bins   []string // six-digit issuer identification numbers
+	length int
+}
+
+// brands lists test BINs the networks and processors publish for
+// integration testing
+var brands = map[string]brand{
+	"visa":       {bins: []string{"411111", "424242", "401288", "400005"}, length: 16},
+	"mastercard": {bins: []string{"555555", "510510", "520082", "222300"}, length: 16},
+	"amex":       {bins: []string{"378282", "371449", "378734"}, length: 15},
+}
+
+// Brands lists the brands PAN can number
+func Brands() []string {
+	names := make([]string, 0, len(brands))
+	for name := range brands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PAN draws a card number of brand: a test BIN, random account digits and
+// the Luhn check digit
+func PAN(src *rng.Source, brandName string) (string, error) {
+	b, ok := brands[strings.ToLower(brandName)]
+	if !ok {
+		return "", fmt.Errorf("unknown card brand %q (known: %s)", brandName, strings.Join(Brands(), ", "))
+	}
+	digits := []byte(b.bins[src.Intn(len(b.bins))])
+	for len(digits) < b.length-1 {
+		digits = append(digits, '0'+byte(src.Intn(10)))
+	}
+	return string(append(digits, checkDigit(string(digits)))), nil
+}
+
+// checkDigit computes the Luhn digit that makes payload+digit valid
+func checkDigit(payload string) byte {
+	sum := 0
+	for i := range payload {
+		d := int(payload[len(payload)-1-i] - '0')
+		// Double every second digit from the right, starting with the last
+		// payload digit, which sits left of the check digit
+		if i%2 == 0 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return '0' + byte((10-sum%10)%10)
+}
+
+// Valid reports whether pan is all digits and passes the Luhn check
+func Valid(pan string) bool {
+	if len(pan) < 2 {
+		return false
+	}
+	for _, r := range pan {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return checkDigit(pan[:len(pan)-1]) == pan[len(pan)-1]
+}
+
+// LastFour is the part of a PAN that may be shown and stored in the clear
+func LastFour(pan string) string {
+	return pan[len(pan)-4:]
+}
+
+// Hasher turns PANs into the lookup hash stored in place of the number:
+// HMAC-SHA256 under a key, or plain SHA-256 without one. It is safe for
+// concurrent use.
+type Hasher struct {
+	key []byte
+}
+
+// NewHasher returns a hasher keyed with key, or an unkeyed one if key is
+// empty
+func NewHasher(key string) Hasher {
+	return Hasher{key: []byte(key)}
+}
+
+// Hash returns the hex digest of pan
+func (h Hasher) Hash(pan string) string {
+	var mac hash.Hash
+	if len(h.key) > 0 {
+		mac = hmac.New(sha256.New, h.key)
+	} else {
+		mac = sha256.New()
+	}
+	mac.Write([]byte(pan))
+	return hex.EncodeToString(mac.Sum(nil))
+}
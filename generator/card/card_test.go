@@ -0,0 +1,70 @@
+package card
+
+import (
+	"testing"
+	"time"
+
+	"datagenerator/generator/rng"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		pan  string
+		want bool
+	}{
+		{"4111111111111111", true},
+		{"4242424242424242", true},
+		{"4012888888881881", true},
+		{"5555555555554444", true},
+		{"5105105105105100", true},
+		{"378282246310005", true},
+		{"371449635398431", true},
+		{"4111111111111112", false},
+		{"5555555555554443", false},
+		{"378282246310006", false},
+		{"4111-1111-1111-1111", false},
+		{"0", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.pan); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.pan, got, tt.want)
+		}
+	}
+}
+
+func TestCheckDigit(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    byte
+	}{
+		{"411111111111111", '1'},
+		{"555555555555444", '4'},
+		{"37828224631000", '5'},
+		{"7992739871", '3'},
+		{"0", '0'},
+	}
+	for _, tt := range tests {
+		if got := checkDigit(tt.payload); got != tt.want {
+			t.Errorf("checkDigit(%q) = %c, want %c", tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestPAN(t *testing.T) {
+	src := rng.New(1, time.Time{})
+	for _, name := range Brands() {
+		for range 100 {
+			pan, err := PAN(src, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pan) != brands[name].length || !Valid(pan) {
+				t.Fatalf("PAN(%s) = %s, want a valid %d-digit number", name, pan, brands[name].length)
+			}
+		}
+	}
+	if _, err := PAN(src, "discover"); err == nil {
+		t.Error("PAN(discover) succeeded, want an unknown brand error")
+	}
+}
@@ -0,0 +1,304 @@
+// Package dataset describes tables declaratively (columns with canonical
+// types, keys, indexes, partitioning and per-column generators) and renders
+// them for each SQL dialect, MongoDB and Elasticsearch.
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset is a named set of tables loaded from a YAML spec
+type Dataset struct {
+	Name   string   `yaml:"name"`
+	Tables []*Table `yaml:"tables"`
+}
+
+// Table describes one table, collection or index
+type Table struct {
+	Name       string     `yaml:"name"`
+	Columns    []*Column  `yaml:"columns"`
+	PrimaryKey []string   `yaml:"primary_key"`
+	Unique     [][]string `yaml:"unique"`
+	Indexes    []Index    `yaml:"indexes"`
+	Partition  *Partition `yaml:"partition"`
+	// DocumentID names the column MongoDB and Elasticsearch use as _id, so
+	// reruns with the same seed overwrite instead of duplicating documents
+	DocumentID string `yaml:"document_id"`
+}
+
+// Column is a single column and, optionally, how to generate its values
+type Column struct {
+	Name       string     `yaml:"name"`
+	Type       Type       `yaml:"type"`
+	NotNull    bool       `yaml:"not_null"`
+	Identity   bool       `yaml:"identity"` // database assigned, never generated
+	Unique     bool       `yaml:"unique"`
+	Default    *Default   `yaml:"default"`
+	Check      *Check     `yaml:"check"`
+	References *Reference `yaml:"references"`
+	// Searchable=false keeps the column out of the Elasticsearch inverted index
+	Searchable *bool      `yaml:"searchable"`
+	Generate   *Generator `yaml:"generate"`
+	// Mutable columns are rewritten with fresh values by update workloads
+	Mutable bool `yaml:"mutable"`
+}
+
+// Index is a secondary index. Dialects limits it to the listed renderers
+// (postgres, mysql, mssql, oracle, mongodb); empty means all of them.
+type Index struct {
+	Name     string   `yaml:"name"`
+	Columns  []string `yaml:"columns"`
+	Unique   bool     `yaml:"unique"`
+	Sparse   bool     `yaml:"sparse"` // MongoDB only
+	Dialects []string `yaml:"dialects"`
+}
+
+// AppliesTo reports whether the index should be rendered for dialect
+func (i Index) AppliesTo(dialect string) bool {
+	return len(i.Dialects) == 0 || slices.Contains(i.Dialects, dialect)
+}
+
+// Default is a column default: either the "now" / "today" functions or a literal
+type Default struct {
+	Func    string // "now" or "today"
+	Literal any
+}
+
+func (d *Default) UnmarshalYAML(node *yaml.Node) error {
+	if node.Tag == "!!str" && (node.Value == "now" || node.Value == "today") {
+		d.Func = node.Value
+		return nil
+	}
+	return node.Decode(&d.Literal)
+}
+
+// Check is a value constraint rendered as a CHECK clause
+type Check struct {
+	Min *float64 `yaml:"min"`
+	Max *float64 `yaml:"max"`
+	In  []string `yaml:"in"`
+}
+
+// Reference is a foreign key, written in specs as "table.column"
+type Reference struct {
+	Table    string
+	Column   string
+	OnDelete string // "", "cascade", "set null" or "restrict"
+}
+
+func (r *Reference) UnmarshalYAML(node *yaml.Node) error {
+	var target string
+	if node.Kind == yaml.ScalarNode {
+		target = node.Value
+	} else {
+		var full struct {
+			Target   string `yaml:"target"`
+			OnDelete string `yaml:"on_delete"`
+		}
+		if err := node.Decode(&full); err != nil {
+			return err
+		}
+		target, r.OnDelete = full.Target, strings.ToLower(full.OnDelete)
+	}
+
+	table, column, ok := strings.Cut(target, ".")
+	if !ok || table == "" || column == "" {
+		return fmt.Errorf("line %d: reference %q must be table.column", node.Line, target)
+	}
+	r.Table, r.Column = table, column
+	return nil
+}
+
+// Lookup returns the named table
+func (d *Dataset) Lookup(name string) (*Table, error) {
+	for _, t := range d.Tables {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	names := make([]string, len(d.Tables))
+	for i, t := range d.Tables {
+		names[i] = t.Name
+	}
+	return nil, fmt.Errorf("dataset %s has no table %q (tables: %s)", d.Name, name, strings.Join(names, ", "))
+}
+
+// Column returns the named column, or nil
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// IdentityColumn returns t's identity column, or nil
+func (t *Table) IdentityColumn() *Column {
+	for _, c := range t.Columns {
+		if c.Identity {
+			return c
+		}
+	}
+	return nil
+}
+
+// Generated returns the columns that have a generator, in spec order. These
+// are the columns written by inserts; the rest are left to database defaults.
+func (t *Table) Generated() []*Column {
+	var cols []*Column
+	for _, c := range t.Columns {
+		if c.Generate != nil {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// Mutable returns the generated columns update workloads rewrite
+func (t *Table) Mutable() []*Column {
+	var cols []*Column
+	for _, c := range t.Generated() {
+		if c.Mutable {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
+
+// IndexName returns the index name, deriving one from the table and columns if unset
+func (t *Table) IndexName(i Index) string {
+	if i.Name != "" {
+		return i.Name
+	}
+	return "idx_" + t.Name + "_" + strings.Join(i.Columns, "_")
+}
+
+// Validate checks that every name a table refers to exists and that keys
+// are compatible with partitioning on every dialect
+func (d *Dataset) Validate() error {
+	if d.Name == "" {
+		return fmt.Errorf("dataset has no name")
+	}
+	if len(d.Tables) == 0 {
+		return fmt.Errorf("dataset %s has no tables", d.Name)
+	}
+
+	tables := map[string]*Table{}
+	for _, t := range d.Tables {
+		if t.Name == "" {
+			return fmt.Errorf("dataset %s: table with no name", d.Name)
+		}
+		if tables[t.Name] != nil {
+			return fmt.Errorf("dataset %s: duplicate table %s", d.Name, t.Name)
+		}
+		tables[t.Name] = t
+	}
+
+	for _, t := range d.Tables {
+		if err := t.validate(tables); err != nil {
+			return fmt.Errorf("dataset %s: table %s: %w", d.Name, t.Name, err)
+		}
+	}
+	return nil
+}
+
+func (t *Table) validate(tables map[string]*Table) error {
+	if len(t.Columns) == 0 {
+		return fmt.Errorf("no columns")
+	}
+
+	seen := map[string]bool{}
+	for _, c := range t.Columns {
+		if c.Name == "" || c.Type.Kind == "" {
+			return fmt.Errorf("every column needs a name and a type")
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate column %s", c.Name)
+		}
+		seen[c.Name] = true
+
+		if c.Identity && (!c.Type.IsInteger() || c.Generate != nil) {
+			return fmt.Errorf("column %s: identity columns must be integers without a generator", c.Name)
+		}
+		if c.Mutable && (c.Generate == nil || slices.Contains(t.PrimaryKey, c.Name) ||
+			(t.Partition != nil && t.Partition.Column == c.Name)) {
+			return fmt.Errorf("column %s: mutable columns must be generated and outside the primary and partition keys", c.Name)
+		}
+		if ref := c.References; ref != nil {
+			target := tables[ref.Table]
+			if target == nil || target.Column(ref.Column) == nil {
+				return fmt.Errorf("column %s references unknown column %s.%s", c.Name, ref.Table, ref.Column)
+			}
+		}
+	}
+
+	keys := [][]string{t.PrimaryKey}
+	keys = append(keys, t.Unique...)
+	for _, i := range t.Indexes {
+		keys = append(keys, i.Columns)
+	}
+	for _, key := range keys {
+		for _, name := range key {
+			if !seen[name] {
+				return fmt.Errorf("unknown column %s in key or index", name)
+			}
+		}
+	}
+	if t.DocumentID != "" && !seen[t.DocumentID] {
+		return fmt.Errorf("document_id names unknown column %s", t.DocumentID)
+	}
+
+	if p := t.Partition; p != nil {
+		if err := p.validate(t); err != nil {
+			return fmt.Errorf("partition: %w", err)
+		}
+		// Postgres, MySQL, SQL Server and Oracle all require unique keys on a
+		// partitioned table to contain the partition column
+		for _, key := range append([][]string{t.PrimaryKey}, t.Unique...) {
+			if len(key) > 0 && !slices.Contains(key, p.Column) {
+				return fmt.Errorf("key (%s) must include partition column %s", strings.Join(key, ", "), p.Column)
+			}
+		}
+		for _, c := range t.Columns {
+			if c.Unique {
+				return fmt.Errorf("column %s: single column unique constraints are not allowed on partitioned tables", c.Name)
+			}
+		}
+	}
+
+	_, err := compile(t)
+	return err
+}
+
+// checkClause renders c's CHECK condition, shared by every SQL dialect
+func checkClause(c *Column) string {
+	var conds []string
+	if c.Check.Min != nil {
+		conds = append(conds, fmt.Sprintf("%s >= %s", c.Name, formatNumber(*c.Check.Min)))
+	}
+	if c.Check.Max != nil {
+		conds = append(conds, fmt.Sprintf("%s <= %s", c.Name, formatNumber(*c.Check.Max)))
+	}
+	if len(c.Check.In) > 0 {
+		quoted := make([]string, len(c.Check.In))
+		for i, v := range c.Check.In {
+			quoted[i] = quoteString(v)
+		}
+		conds = append(conds, fmt.Sprintf("%s IN (%s)", c.Name, strings.Join(quoted, ", ")))
+	}
+	return "CHECK (" + strings.Join(conds, " AND ") + ")"
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,83 @@
+package dataset
+
+import (
+	"math"
+	"strings"
+)
+
+// ESIndex is the Elasticsearch index for t: the table name in kebab-case
+func ESIndex(t *Table) string {
+	return strings.ReplaceAll(t.Name, "_", "-")
+}
+
+// ESMapping returns the Elasticsearch "mappings" object for t
+func ESMapping(t *Table) map[string]any {
+	properties := map[string]any{}
+	for _, c := range t.Columns {
+		if c.Identity {
+			continue
+		}
+		field := esField(c.Type)
+		if c.Searchable != nil && !*c.Searchable {
+			field["index"] = false
+		}
+		properties[c.Name] = field
+	}
+	return map[string]any{"properties": properties}
+}
+
+func esField(t Type) map[string]any {
+	switch t.Kind {
+	case TinyInt:
+		return map[string]any{"type": "byte"}
+	case SmallInt:
+		return map[string]any{"type": "short"}
+	case Int:
+		return map[string]any{"type": "integer"}
+	case BigInt:
+		return map[string]any{"type": "long"}
+	case Decimal:
+		return map[string]any{"type": "scaled_float", "scaling_factor": math.Pow10(t.Scale)}
+	case Varchar, Char, UUID:
+		return map[string]any{"type": "keyword"}
+	case Bool:
+		return map[string]any{"type": "boolean"}
+	case Date:
+		return map[string]any{"type": "date", "format": "yyyy-MM-dd"}
+	case Timestamp, TimestampTZ:
+		return map[string]any{"type": "date", "format": "strict_date_optional_time||epoch_millis"}
+	case IP:
+		return map[string]any{"type": "ip"}
+	case JSON:
+		return map[string]any{"type": "flattened"}
+	case Bytes:
+		return map[string]any{"type": "binary"}
+	default:
+		return map[string]any{"type": "text"}
+	}
+}
+
+// DocumentValue converts a canonical value for a JSON document: numbers
+// and booleans stay native, everything else uses FormatValue
+func DocumentValue(c *Column, v any) any {
+	switch v.(type) {
+	case nil, int64, float64, bool:
+		return v
+	}
+	if c.Type.Kind == JSON {
+		return v
+	}
+	return FormatValue(c, v)
+}
+
+// MongoIndexes returns the indexes that apply to MongoDB
+func MongoIndexes(t *Table) []Index {
+	var indexes []Index
+	for _, i := range t.Indexes {
+		if i.AppliesTo("mongodb") {
+			i.Name = t.IndexName(i)
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
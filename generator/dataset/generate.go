@@ -0,0 +1,566 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"slices"
+	"sort"
+	"time"
+
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/geo"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/timeline"
+	"datagenerator/generator/weighted"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Row holds one generated value per Table.Generated() column, in order.
+// Values use canonical Go types: int64 for integers, float64 for decimals,
+// string, bool, time.Time (UTC) for dates and timestamps, [16]byte for
+// uuid, netip.Addr for ip, []byte for bytes and any JSON-marshalable value
+// for json. A nil value is NULL.
+type Row []any
+
+// Generator is a column's generate block. Kind selects the registered
+// factory; the remaining keys are that kind's parameters.
+type Generator struct {
+	Kind   string
+	params yaml.Node
+}
+
+func (g *Generator) UnmarshalYAML(node *yaml.Node) error {
+	// "generate: uuid" is shorthand for a generator without parameters
+	if node.Kind == yaml.ScalarNode {
+		g.Kind = node.Value
+		return nil
+	}
+	var head struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := node.Decode(&head); err != nil {
+		return err
+	}
+	g.Kind, g.params = head.Kind, *node
+	return nil
+}
+
+// Decode decodes the generator's parameters into v
+func (g *Generator) Decode(v any) error {
+	if g.params.Kind == 0 {
+		return nil
+	}
+	return g.params.Decode(v)
+}
+
+// ValueFunc produces one column value. row holds the values already
+// generated for the columns before this one.
+type ValueFunc func(src *rng.Source, row Row) any
+
+// Factory builds the ValueFunc for column c of table t, validating the
+// generator parameters once up front
+type Factory func(t *Table, c *Column, g *Generator) (ValueFunc, error)
+
+var factories = map[string]Factory{}
+
+// Register makes a generator kind available to specs. Packages providing
+// additional kinds call it from init.
+func Register(kind string, f Factory) {
+	if _, dup := factories[kind]; dup {
+		panic("dataset: generator kind registered twice: " + kind)
+	}
+	factories[kind] = f
+}
+
+// Kinds lists the registered generator kinds
+func Kinds() []string {
+	kinds := make([]string, 0, len(factories))
+	for k := range factories {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+func init() {
+	Register("int", newInt)
+	Register("decimal", newDecimal)
+	Register("hash", newHash)
+	Register("uuid", newUUID)
+	Register("choice", newChoice)
+	Register("timestamp", newTimestamp)
+	Register("now", newNow)
+	Register("date_of", newDateOf)
+	Register("ipv4", newIPv4)
+	Register("geo_ip", newGeoIP)
+	Register("const", newConst)
+	Register("sequence", newSequence)
+}
+
+// RowGenerator produces rows for a table from a deterministic source
+type RowGenerator struct {
+	src   *rng.Source
+	funcs []ValueFunc
+}
+
+// NewRowGenerator compiles the table's generators
+func NewRowGenerator(t *Table, src *rng.Source) (*RowGenerator, error) {
+	funcs, err := compile(t)
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", t.Name, err)
+	}
+	return &RowGenerator{src: src, funcs: funcs}, nil
+}
+
+func compile(t *Table) ([]ValueFunc, error) {
+	var funcs []ValueFunc
+	for _, c := range t.Generated() {
+		factory, ok := factories[c.Generate.Kind]
+		if !ok {
+			return nil, fmt.Errorf("column %s: unknown generator kind %q (known: %v)", c.Name, c.Generate.Kind, Kinds())
+		}
+		fn, err := factory(t, c, c.Generate)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %s generator: %w", c.Name, c.Generate.Kind, err)
+		}
+		funcs = append(funcs, fn)
+	}
+	return funcs, nil
+}
+
+// Next generates one row
+func (g *RowGenerator) Next() Row {
+	row := make(Row, 0, len(g.funcs))
+	for _, fn := range g.funcs {
+		row = append(row, fn(g.src, row))
+	}
+	return row
+}
+
+// Batch fills rows with freshly generated rows, reusing its backing array
+func (g *RowGenerator) Batch(rows []Row, n int) []Row {
+	rows = rows[:0]
+	for range n {
+		rows = append(rows, g.Next())
+	}
+	return rows
+}
+
+// generatedIndex returns the position of column name in a Row of t
+func generatedIndex(t *Table, name string) int {
+	return slices.IndexFunc(t.Generated(), func(c *Column) bool { return c.Name == name })
+}
+
+// Built-in generator kinds
+
+func requireKind(c *Column, ok bool) error {
+	if !ok {
+		return fmt.Errorf("not supported for %s columns", c.Type)
+	}
+	return nil
+}
+
+// int: integer in [min, max], uniform unless dist picks a distribution
+func newInt(_ *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.IsInteger()); err != nil {
+		return nil, err
+	}
+	var p struct {
+		Min                 int64 `yaml:"min"`
+		Max                 int64 `yaml:"max"`
+		distribution.Params `yaml:",inline"`
+	}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Max < p.Min {
+		return nil, fmt.Errorf("max %d is below min %d", p.Max, p.Min)
+	}
+	if p.Uniform() {
+		span := p.Max - p.Min + 1
+		return func(src *rng.Source, _ Row) any {
+			return p.Min + src.Int63n(span)
+		}, nil
+	}
+	// Zipf draws whole ranks; anything else is rounded down over
+	// [min, max+1), which gives min and max the same width as the rest
+	hi := float64(p.Max)
+	if p.Dist != "zipf" {
+		hi++
+	}
+	d, err := p.New(float64(p.Min), hi)
+	if err != nil {
+		return nil, err
+	}
+	return func(src *rng.Source, _ Row) any {
+		return min(int64(math.Floor(d.Sample(src))), p.Max)
+	}, nil
+}
+
+// decimal: value in [min, max] rounded to the column scale, uniform unless
+// dist picks a distribution
+func newDecimal(_ *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.Kind == Decimal); err != nil {
+		return nil, err
+	}
+	var p struct {
+		Min                 float64 `yaml:"min"`
+		Max                 float64 `yaml:"max"`
+		distribution.Params `yaml:",inline"`
+	}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	d, err := p.New(p.Min, p.Max)
+	if err != nil {
+		return nil, err
+	}
+	unit := math.Pow10(c.Type.Scale)
+	return func(src *rng.Source, _ Row) any {
+		return math.Round(d.Sample(src)*unit) / unit
+	}, nil
+}
+
+// hash: random non-negative 63-bit value standing in for a hashed string
+func newHash(_ *Table, c *Column, _ *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.Kind == BigInt); err != nil {
+		return nil, err
+	}
+	return func(src *rng.Source, _ Row) any { return src.Int63() }, nil
+}
+
+// uuid: 16 random bytes
+func newUUID(_ *Table, c *Column, _ *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.Kind == UUID); err != nil {
+		return nil, err
+	}
+	return func(src *rng.Source, _ Row) any {
+		var id [16]byte
+		src.Bytes(id[:])
+		return id
+	}, nil
+}
+
+// choice: one of values, optionally weighted, with weights optionally
+// depending on the value of an earlier column
+func newChoice(t *Table, c *Column, g *Generator) (ValueFunc, error) {
+	var p struct {
+		Values  []any     `yaml:"values"`
+		Weights []float64 `yaml:"weights"`
+		Given   *struct {
+			Column  string               `yaml:"column"`
+			Weights map[string][]float64 `yaml:"weights"`
+		} `yaml:"given"`
+	}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+
+	values := make([]any, len(p.Values))
+	for i, v := range p.Values {
+		converted, err := convert(c, v)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = converted
+	}
+
+	if p.Given == nil {
+		choice, err := weighted.New(values, p.Weights)
+		if err != nil {
+			return nil, err
+		}
+		return func(src *rng.Source, _ Row) any { return choice.Pick(src) }, nil
+	}
+
+	// Conditional weights, keyed by the text of an earlier column's value
+	idx, self := generatedIndex(t, p.Given.Column), generatedIndex(t, c.Name)
+	if idx < 0 || idx > self {
+		return nil, fmt.Errorf("given column must name a generated column before %s, got %q", c.Name, p.Given.Column)
+	}
+	choice, err := weighted.NewConditional(values, p.Weights, p.Given.Weights)
+	if err != nil {
+		return nil, err
+	}
+	return func(src *rng.Source, row Row) any {
+		return choice.Pick(src, fmt.Sprint(row[idx]))
+	}, nil
+}
+
+// timestampParams places a timestamp window either relative to the frozen
+// clock (within) or at fixed start and end times
+type timestampParams struct {
+	Within    time.Duration  `yaml:"within"`
+	Start     string         `yaml:"start"` // YYYY-MM-DD or RFC 3339
+	End       string         `yaml:"end"`   // exclusive, defaults to the frozen clock
+	Shape     timeline.Shape `yaml:"shape"`
+	Precision time.Duration  `yaml:"precision"`
+}
+
+func decodeTimestamp(g *Generator) (timestampParams, error) {
+	p := timestampParams{Shape: timeline.Uniform, Precision: timeline.DefaultPrecision}
+	if err := g.Decode(&p); err != nil {
+		return p, err
+	}
+	if (p.Within == 0) == (p.Start == "") {
+		return p, fmt.Errorf("set exactly one of within and start")
+	}
+	if p.Within < 0 {
+		return p, fmt.Errorf("within must be positive, got %s", p.Within)
+	}
+	if p.Within != 0 && p.End != "" {
+		return p, fmt.Errorf("end needs start, within always ends at the frozen clock")
+	}
+	if _, err := timeline.ParseShape(string(p.Shape)); err != nil {
+		return p, err
+	}
+	if p.Precision <= 0 {
+		return p, fmt.Errorf("precision must be positive, got %s", p.Precision)
+	}
+	// A fixed window can be checked now; one ending at the frozen clock is
+	// checked on first use
+	if p.Start != "" && p.End != "" {
+		if _, err := p.model(time.Time{}); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// model resolves the window against the frozen clock now
+func (p timestampParams) model(now time.Time) (timeline.Model, error) {
+	m := timeline.Model{Start: now.Add(-p.Within), End: now, Shape: p.Shape, Precision: p.Precision}
+	var err error
+	if p.Start != "" {
+		if m.Start, err = timeline.ParseTime(p.Start); err != nil {
+			return m, err
+		}
+	}
+	if p.End != "" {
+		if m.End, err = timeline.ParseTime(p.End); err != nil {
+			return m, err
+		}
+	}
+	return m, m.Validate()
+}
+
+// timestamp: instant in a window, spread by a timeline shape
+func newTimestamp(_ *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.IsTime()); err != nil {
+		return nil, err
+	}
+	p, err := decodeTimestamp(g)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sources share one frozen clock, so the model is resolved once
+	var model timeline.Model
+	return func(src *rng.Source, _ Row) any {
+		if model.End.IsZero() {
+			if model, err = p.model(src.Now.UTC()); err != nil {
+				panic(fmt.Sprintf("column %s: %v", c.Name, err))
+			}
+		}
+		return model.Draw(src)
+	}, nil
+}
+
+// now: the frozen clock
+func newNow(_ *Table, c *Column, _ *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.IsTime()); err != nil {
+		return nil, err
+	}
+	return func(src *rng.Source, _ Row) any { return src.Now.UTC() }, nil
+}
+
+// date_of: the UTC day of an earlier timestamp column
+func newDateOf(t *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.Kind == Date); err != nil {
+		return nil, err
+	}
+	var p struct {
+		Column string `yaml:"column"`
+	}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	idx, self := generatedIndex(t, p.Column), generatedIndex(t, c.Name)
+	if idx < 0 || idx > self || !t.Column(p.Column).Type.IsTime() {
+		return nil, fmt.Errorf("column must name a generated timestamp column before %s, got %q", c.Name, p.Column)
+	}
+	return func(_ *rng.Source, row Row) any {
+		return row[idx].(time.Time).UTC().Truncate(24 * time.Hour)
+	}, nil
+}
+
+// TimeWindow returns the earliest and latest values column's generator
+// produces under the frozen clock now. It reports false when the column is
+// not generated within a fixed window.
+func (t *Table) TimeWindow(column string, now time.Time) (from, to time.Time, ok bool) {
+	c := t.Column(column)
+	if c == nil || c.Generate == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	now = now.UTC()
+	switch c.Generate.Kind {
+	case "now":
+		return now, now, true
+	case "timestamp":
+		p, err := decodeTimestamp(c.Generate)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		m, err := p.model(now)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		return m.Start, m.End, true
+	case "date_of":
+		var p struct {
+			Column string `yaml:"column"`
+		}
+		if err := c.Generate.Decode(&p); err != nil || p.Column == column {
+			return time.Time{}, time.Time{}, false
+		}
+		return t.TimeWindow(p.Column, now)
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// ipv4: random address inside cidr
+func newIPv4(_ *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.Kind == IP); err != nil {
+		return nil, err
+	}
+	p := struct {
+		CIDR string `yaml:"cidr"`
+	}{CIDR: "0.0.0.0/0"}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	prefix, err := netip.ParsePrefix(p.CIDR)
+	if err != nil || !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("cidr must be an IPv4 prefix, got %q", p.CIDR)
+	}
+	base := prefix.Masked().Addr().As4()
+	hostBits := 32 - prefix.Bits()
+	return func(src *rng.Source, _ Row) any {
+		n := uint32(base[0])<<24 | uint32(base[1])<<16 | uint32(base[2])<<8 | uint32(base[3])
+		if hostBits > 0 {
+			n |= uint32(src.Int63n(int64(1) << hostBits))
+		}
+		return netip.AddrFrom4([4]byte{byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)})
+	}, nil
+}
+
+// geo_ip: public address from the ISP blocks of the country named by an
+// earlier column, IPv6 for a share of rows
+func newGeoIP(t *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.Kind == IP); err != nil {
+		return nil, err
+	}
+	var p struct {
+		Country string  `yaml:"country"`
+		IPv6    float64 `yaml:"ipv6"`
+	}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.IPv6 < 0 || p.IPv6 > 1 {
+		return nil, fmt.Errorf("ipv6 must be a share between 0 and 1, got %v", p.IPv6)
+	}
+	idx, self := generatedIndex(t, p.Country), generatedIndex(t, c.Name)
+	if idx < 0 || idx >= self {
+		return nil, fmt.Errorf("country must name a generated column before %s, got %q", c.Name, p.Country)
+	}
+	// Resolve every country the column can produce up front, so a spec
+	// naming a country outside the table fails at compile time
+	byCode := map[string]*geo.Country{}
+	if cc := t.Column(p.Country); cc.Generate.Kind == "choice" {
+		var choice struct {
+			Values []string `yaml:"values"`
+		}
+		if err := cc.Generate.Decode(&choice); err != nil {
+			return nil, err
+		}
+		for _, code := range choice.Values {
+			country, err := geo.Lookup(code)
+			if err != nil {
+				return nil, err
+			}
+			byCode[code] = country
+		}
+	}
+	return func(src *rng.Source, row Row) any {
+		code := fmt.Sprint(row[idx])
+		country, ok := byCode[code]
+		if !ok {
+			var err error
+			if country, err = geo.Lookup(code); err != nil {
+				panic(fmt.Sprintf("column %s: %v", c.Name, err))
+			}
+		}
+		return country.IP(src, p.IPv6)
+	}, nil
+}
+
+// const: the same value for every row
+func newConst(_ *Table, c *Column, g *Generator) (ValueFunc, error) {
+	var p struct {
+		Value any `yaml:"value"`
+	}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	v, err := convert(c, p.Value)
+	if err != nil {
+		return nil, err
+	}
+	return func(*rng.Source, Row) any { return v }, nil
+}
+
+// sequence: start plus the next identifier of the source's ID range, so
+// parallel workers emit disjoint keys
+func newSequence(_ *Table, c *Column, g *Generator) (ValueFunc, error) {
+	if err := requireKind(c, c.Type.IsInteger()); err != nil {
+		return nil, err
+	}
+	p := struct {
+		Start int64 `yaml:"start"`
+	}{Start: 1}
+	if err := g.Decode(&p); err != nil {
+		return nil, err
+	}
+	return func(src *rng.Source, _ Row) any { return p.Start + src.IDs.Take() }, nil
+}
+
+// convert turns a YAML scalar into the canonical Go type for c
+func convert(c *Column, v any) (any, error) {
+	switch {
+	case v == nil:
+		return nil, nil
+	case c.Type.IsInteger():
+		if n, ok := v.(int); ok {
+			return int64(n), nil
+		}
+	case c.Type.Kind == Decimal:
+		switch n := v.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+	case c.Type.Kind == Bool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case c.Type.Kind == Varchar, c.Type.Kind == Char, c.Type.Kind == Text:
+		return fmt.Sprint(v), nil
+	case c.Type.Kind == JSON:
+		return v, nil
+	}
+	return nil, fmt.Errorf("value %v does not fit a %s column", v, c.Type)
+}
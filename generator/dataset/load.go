@@ -0,0 +1,59 @@
+package dataset
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed specs/*.yaml
+var builtinSpecs embed.FS
+
+// Builtins lists the names of the datasets compiled into the binary
+func Builtins() []string {
+	entries, _ := builtinSpecs.ReadDir("specs")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), path.Ext(e.Name())))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the built-in dataset called name, or reads and validates the
+// spec at name if it is a path to a YAML file
+func Load(name string) (*Dataset, error) {
+	data, err := builtinSpecs.ReadFile("specs/" + name + ".yaml")
+	if err != nil {
+		if data, err = os.ReadFile(name); err != nil {
+			return nil, fmt.Errorf("dataset %q is neither built in (%s) nor a readable file: %w",
+				name, strings.Join(Builtins(), ", "), err)
+		}
+	}
+	ds, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("dataset %s: %w", name, err)
+	}
+	return ds, nil
+}
+
+// Parse decodes and validates a YAML spec
+func Parse(data []byte) (*Dataset, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	var ds Dataset
+	if err := dec.Decode(&ds); err != nil {
+		return nil, err
+	}
+	if err := ds.Validate(); err != nil {
+		return nil, err
+	}
+	return &ds, nil
+}
@@ -0,0 +1,186 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type mssqlDialect struct{}
+
+// MSSQL renders tables as SQL Server DDL on a partition function and scheme
+var MSSQL Dialect = mssqlDialect{}
+
+func (mssqlDialect) Name() string { return "mssql" }
+
+func mssqlType(c *Column) string {
+	t := c.Type
+	var name string
+	switch t.Kind {
+	case TinyInt:
+		name = "TINYINT"
+	case SmallInt:
+		name = "SMALLINT"
+	case Int:
+		name = "INT"
+	case BigInt:
+		name = "BIGINT"
+	case Decimal:
+		name = fmt.Sprintf("DECIMAL(%d, %d)", t.Precision, t.Scale)
+	case Varchar:
+		name = fmt.Sprintf("VARCHAR(%d)", t.Length)
+	case Char:
+		name = fmt.Sprintf("CHAR(%d)", t.Length)
+	case Bool:
+		name = "BIT"
+	case Date:
+		name = "DATE"
+	case Timestamp:
+		name = "DATETIME2(3)"
+	case TimestampTZ:
+		name = "DATETIMEOFFSET(3)"
+	case UUID:
+		name = "UNIQUEIDENTIFIER"
+	case IP:
+		name = "VARCHAR(45)" // Supports both IPv4 and IPv6
+	case JSON:
+		name = "NVARCHAR(MAX)"
+	case Bytes:
+		name = "VARBINARY(MAX)"
+		if t.Length > 0 {
+			name = fmt.Sprintf("VARBINARY(%d)", t.Length)
+		}
+	default:
+		name = "VARCHAR(MAX)"
+	}
+	if c.Identity {
+		name += " IDENTITY(1,1)"
+	}
+	return name
+}
+
+var mssqlColumns = columnStyle{
+	typeName: mssqlType,
+	now: func(c *Column) string {
+		if c.Type.Kind == TimestampTZ {
+			return "SYSDATETIMEOFFSET()"
+		}
+		return "SYSDATETIME()"
+	},
+	today:       func(*Column) string { return "CAST(GETDATE() AS DATE)" },
+	trueFalse:   [2]string{"1", "0"},
+	foreignKeys: true,
+}
+
+func (mssqlDialect) CreateTable(t *Table) []Statement {
+	var stmts []Statement
+	defs := append(mssqlColumns.columnDefs(t), tableConstraints(t, "PRIMARY KEY CLUSTERED")...)
+	create := fmt.Sprintf("CREATE TABLE %s %s", t.Name, createTableBody(defs))
+
+	if p := t.Partition; p != nil {
+		function, scheme := mssqlPartitionObjects(t)
+
+		// RANGE RIGHT boundaries: each range start plus the final end. The
+		// partition above the last boundary catches later rows, so Future
+		// needs no extra object here.
+		ranges := p.Ranges()
+		bounds := make([]string, 0, len(ranges)+1)
+		for _, r := range ranges {
+			bounds = append(bounds, "'"+r.From.Format(time.DateOnly)+"'")
+		}
+		bounds = append(bounds, "'"+ranges[len(ranges)-1].To.Format(time.DateOnly)+"'")
+
+		stmts = append(stmts,
+			Statement{Object: "PARTITION FUNCTION", Name: function, SQL: fmt.Sprintf(`IF NOT EXISTS (SELECT * FROM sys.partition_functions WHERE name = '%s')
+BEGIN
+    CREATE PARTITION FUNCTION %s(%s)
+    AS RANGE RIGHT FOR VALUES (%s);
+END;`, function, function, mssqlType(t.Column(p.Column)), strings.Join(bounds, ", "))},
+			Statement{Object: "PARTITION SCHEME", Name: scheme, SQL: fmt.Sprintf(`IF NOT EXISTS (SELECT * FROM sys.partition_schemes WHERE name = '%s')
+BEGIN
+    CREATE PARTITION SCHEME %s
+    AS PARTITION %s ALL TO ([PRIMARY]);
+END;`, scheme, scheme, function)},
+		)
+		create += fmt.Sprintf(" ON %s(%s)", scheme, p.Column)
+	}
+
+	stmts = append(stmts, Statement{Object: "TABLE", Name: t.Name, SQL: fmt.Sprintf(`IF OBJECT_ID(N'%s', N'U') IS NULL
+BEGIN
+%s;
+END;`, t.Name, create)})
+
+	for _, i := range t.Indexes {
+		if !i.AppliesTo("mssql") {
+			continue
+		}
+		unique := ""
+		if i.Unique {
+			unique = "UNIQUE "
+		}
+		name := t.IndexName(i)
+		stmts = append(stmts, Statement{Object: "INDEX", Name: name, SQL: fmt.Sprintf(
+			`IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name = '%s' AND object_id = OBJECT_ID(N'%s'))
+    CREATE %sINDEX %s ON %s (%s);`, name, t.Name, unique, name, t.Name, strings.Join(i.Columns, ", "))})
+	}
+	return stmts
+}
+
+// mssqlPartitionObjects names t's partition function and scheme
+func mssqlPartitionObjects(t *Table) (function, scheme string) {
+	return "pf_" + t.Name, "ps_" + t.Name
+}
+
+func (mssqlDialect) ListPartitions(t *Table) string {
+	function, _ := mssqlPartitionObjects(t)
+	return fmt.Sprintf(`SELECT CONVERT(VARCHAR(10), CAST(v.value AS DATE), 23)
+FROM sys.partition_range_values v
+JOIN sys.partition_functions f ON f.function_id = v.function_id
+WHERE f.name = '%s'`, function)
+}
+
+// AddPartitions splits a new RANGE RIGHT boundary into the partition
+// function for every range start in the window, plus the window's end.
+// Boundaries may go anywhere; SPLIT RANGE moves rows already stored.
+func (mssqlDialect) AddPartitions(t *Table, existing []string, from, to time.Time) []Statement {
+	function, scheme := mssqlPartitionObjects(t)
+	ranges := t.Partition.Cover(from, to)
+	bounds := make([]string, 0, len(ranges)+1)
+	for _, r := range ranges {
+		bounds = append(bounds, r.From.Format(time.DateOnly))
+	}
+	bounds = append(bounds, ranges[len(ranges)-1].To.Format(time.DateOnly))
+
+	var stmts []Statement
+	for _, bound := range bounds {
+		if slices.Contains(existing, bound) {
+			continue
+		}
+		stmts = append(stmts, Statement{Object: "PARTITION", Name: bound, SQL: fmt.Sprintf(
+			`ALTER PARTITION SCHEME %s NEXT USED [PRIMARY];
+ALTER PARTITION FUNCTION %s() SPLIT RANGE ('%s');`, scheme, function, bound)})
+	}
+	return stmts
+}
+
+// Insert uses @pN placeholders; the driver binds positional arguments to them
+func (mssqlDialect) Insert(t *Table) string {
+	return insert(t, func(i int) string { return fmt.Sprintf("@p%d", i) })
+}
+
+func (mssqlDialect) SelectKeys(t *Table, limit int) string {
+	return strings.Replace(orderedKeys(t), "SELECT ", fmt.Sprintf("SELECT TOP (%d) ", limit), 1)
+}
+
+func (mssqlDialect) Update(t *Table) string {
+	return update(t, func(i int) string { return fmt.Sprintf("@p%d", i) })
+}
+
+func (mssqlDialect) Delete(t *Table) string {
+	return deleteByKey(t, func(i int) string { return fmt.Sprintf("@p%d", i) })
+}
+
+func (mssqlDialect) Bind(c *Column, v any) any {
+	return textBind(c, v)
+}
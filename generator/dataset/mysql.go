@@ -0,0 +1,167 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type mysqlDialect struct{}
+
+// MySQL renders tables as MySQL / MariaDB DDL with RANGE partitioning
+var MySQL Dialect = mysqlDialect{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func mysqlType(c *Column) string {
+	t := c.Type
+	var name string
+	switch t.Kind {
+	case TinyInt:
+		name = "TINYINT"
+	case SmallInt:
+		name = "SMALLINT"
+	case Int:
+		name = "INT"
+	case BigInt:
+		name = "BIGINT"
+	case Decimal:
+		name = fmt.Sprintf("DECIMAL(%d, %d)", t.Precision, t.Scale)
+	case Varchar:
+		name = fmt.Sprintf("VARCHAR(%d)", t.Length)
+	case Char:
+		name = fmt.Sprintf("CHAR(%d)", t.Length)
+	case Bool:
+		name = "BOOLEAN"
+	case Date:
+		name = "DATE"
+	case Timestamp:
+		name = "DATETIME(3)"
+	case TimestampTZ:
+		name = "TIMESTAMP(3)"
+	case UUID:
+		name = "BINARY(16)"
+	case IP:
+		name = "VARBINARY(16)"
+	case JSON:
+		name = "JSON"
+	case Bytes:
+		name = "LONGBLOB"
+		if t.Length > 0 {
+			name = fmt.Sprintf("VARBINARY(%d)", t.Length)
+		}
+	default:
+		name = "TEXT"
+	}
+	if c.Identity {
+		name += " AUTO_INCREMENT"
+	}
+	return name
+}
+
+func (mysqlDialect) CreateTable(t *Table) []Statement {
+	style := columnStyle{
+		typeName:  mysqlType,
+		now:       func(*Column) string { return "CURRENT_TIMESTAMP(3)" },
+		today:     func(*Column) string { return "(CURRENT_DATE)" },
+		trueFalse: [2]string{"TRUE", "FALSE"},
+		// MySQL does not support foreign keys on partitioned tables
+		foreignKeys: t.Partition == nil,
+	}
+	defs := style.columnDefs(t)
+
+	// MySQL has no CREATE INDEX IF NOT EXISTS, so indexes go inline
+	for _, i := range t.Indexes {
+		if !i.AppliesTo("mysql") {
+			continue
+		}
+		kind := "INDEX"
+		if i.Unique {
+			kind = "UNIQUE INDEX"
+		}
+		defs = append(defs, fmt.Sprintf("%s %s (%s)", kind, t.IndexName(i), strings.Join(i.Columns, ", ")))
+	}
+	defs = append(defs, tableConstraints(t, "PRIMARY KEY")...)
+
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s %s ENGINE=InnoDB", t.Name, createTableBody(defs))
+	if p := t.Partition; p != nil {
+		create += "\n" + mysqlPartitionClause(t, p)
+	}
+	return []Statement{{Object: "TABLE", Name: t.Name, SQL: create}}
+}
+
+// mysqlPartitionClause partitions DATE and DATETIME columns by TO_DAYS and
+// TIMESTAMP columns by UNIX_TIMESTAMP, the only function MySQL allows on them
+func mysqlPartitionClause(t *Table, p *Partition) string {
+	var parts []string
+	for _, r := range p.Ranges() {
+		parts = append(parts, mysqlPartition(t, r))
+	}
+	if p.Future {
+		parts = append(parts, mysqlFuturePartition)
+	}
+	return fmt.Sprintf("PARTITION BY RANGE (%s(%s)) (\n    %s\n)",
+		mysqlPartitionFunc(t), p.Column, strings.Join(parts, ",\n    "))
+}
+
+func mysqlPartitionFunc(t *Table) string {
+	if t.Column(t.Partition.Column).Type.Kind == TimestampTZ {
+		return "UNIX_TIMESTAMP"
+	}
+	return "TO_DAYS"
+}
+
+func mysqlPartition(t *Table, r Range) string {
+	return fmt.Sprintf("PARTITION %s VALUES LESS THAN (%s('%s'))",
+		r.ShortName(), mysqlPartitionFunc(t), r.To.Format(time.DateOnly))
+}
+
+var mysqlFuturePartition = fmt.Sprintf("PARTITION %s VALUES LESS THAN MAXVALUE", FutureName)
+
+func (mysqlDialect) ListPartitions(t *Table) string {
+	return fmt.Sprintf(`SELECT PARTITION_NAME FROM information_schema.PARTITIONS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '%s' AND PARTITION_NAME IS NOT NULL`, t.Name)
+}
+
+// AddPartitions appends ranges above the highest partition in one ALTER,
+// splitting them off p_future with REORGANIZE PARTITION when it exists
+func (mysqlDialect) AddPartitions(t *Table, existing []string, from, to time.Time) []Statement {
+	ranges := t.Partition.appendAfter(existing, from, to)
+	if len(ranges) == 0 {
+		return nil
+	}
+	parts := make([]string, len(ranges))
+	names := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i], names[i] = mysqlPartition(t, r), r.ShortName()
+	}
+
+	alter := fmt.Sprintf("ALTER TABLE %s ADD PARTITION (\n    %s\n)", t.Name, strings.Join(parts, ",\n    "))
+	if slices.Contains(existing, FutureName) {
+		parts = append(parts, mysqlFuturePartition)
+		alter = fmt.Sprintf("ALTER TABLE %s REORGANIZE PARTITION %s INTO (\n    %s\n)",
+			t.Name, FutureName, strings.Join(parts, ",\n    "))
+	}
+	return []Statement{{Object: "PARTITION", Name: strings.Join(names, ", "), SQL: alter}}
+}
+
+func (mysqlDialect) Insert(t *Table) string {
+	return insert(t, func(int) string { return "?" })
+}
+
+func (mysqlDialect) SelectKeys(t *Table, limit int) string {
+	return orderedKeys(t) + fmt.Sprintf(" LIMIT %d", limit)
+}
+
+func (mysqlDialect) Update(t *Table) string {
+	return update(t, func(int) string { return "?" })
+}
+
+func (mysqlDialect) Delete(t *Table) string {
+	return deleteByKey(t, func(int) string { return "?" })
+}
+
+func (mysqlDialect) Bind(c *Column, v any) any {
+	return binaryBind(c, v)
+}
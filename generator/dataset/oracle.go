@@ -0,0 +1,167 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type oracleDialect struct{}
+
+// Oracle renders tables as Oracle DDL. Identity columns are filled by a
+// <table>_seq sequence and trigger, so loaders can also draw IDs directly.
+var Oracle Dialect = oracleDialect{}
+
+func (oracleDialect) Name() string { return "oracle" }
+
+func oracleType(c *Column) string {
+	t := c.Type
+	switch t.Kind {
+	case TinyInt:
+		return "NUMBER(3)"
+	case SmallInt:
+		return "NUMBER(5)"
+	case Int:
+		return "NUMBER(10)"
+	case BigInt:
+		return "NUMBER(19)"
+	case Decimal:
+		return fmt.Sprintf("NUMBER(%d, %d)", t.Precision, t.Scale)
+	case Varchar:
+		return fmt.Sprintf("VARCHAR2(%d)", t.Length)
+	case Char:
+		return fmt.Sprintf("CHAR(%d)", t.Length)
+	case Bool:
+		return "NUMBER(1)"
+	case Date:
+		return "DATE"
+	case Timestamp:
+		return "TIMESTAMP(3)"
+	case TimestampTZ:
+		return "TIMESTAMP(3) WITH TIME ZONE"
+	case UUID, IP:
+		return "RAW(16)"
+	case Bytes:
+		if t.Length > 0 && t.Length <= 2000 {
+			return fmt.Sprintf("RAW(%d)", t.Length)
+		}
+		return "BLOB"
+	default:
+		return "CLOB"
+	}
+}
+
+var oracleColumns = columnStyle{
+	typeName:    oracleType,
+	now:         func(*Column) string { return "SYSTIMESTAMP" },
+	today:       func(*Column) string { return "TRUNC(SYSDATE)" },
+	trueFalse:   [2]string{"1", "0"},
+	foreignKeys: true,
+}
+
+// SequenceName is the sequence backing t's identity column
+func SequenceName(t *Table) string {
+	return t.Name + "_seq"
+}
+
+func (oracleDialect) CreateTable(t *Table) []Statement {
+	defs := append(oracleColumns.columnDefs(t), tableConstraints(t, "CONSTRAINT pk_"+t.Name+" PRIMARY KEY")...)
+	create := fmt.Sprintf("CREATE TABLE %s %s", t.Name, createTableBody(defs))
+
+	if p := t.Partition; p != nil {
+		var parts []string
+		for _, r := range p.Ranges() {
+			parts = append(parts, fmt.Sprintf("PARTITION %s VALUES LESS THAN (%s)", r.ShortName(), oracleBound(t, r)))
+		}
+		if p.Future {
+			parts = append(parts, fmt.Sprintf("PARTITION %s VALUES LESS THAN (MAXVALUE)", FutureName))
+		}
+		create += fmt.Sprintf("\nPARTITION BY RANGE (%s) (\n    %s\n)", p.Column, strings.Join(parts, ",\n    "))
+	}
+	stmts := []Statement{{Object: "TABLE", Name: t.Name, SQL: create}}
+
+	if id := t.IdentityColumn(); id != nil {
+		seq, trigger := SequenceName(t), "trg_"+t.Name+"_id"
+		stmts = append(stmts,
+			Statement{Object: "SEQUENCE", Name: seq, SQL: fmt.Sprintf(
+				"CREATE SEQUENCE %s START WITH 1 INCREMENT BY 1 CACHE 1000 NOCYCLE", seq)},
+			Statement{Object: "TRIGGER", Name: trigger, SQL: fmt.Sprintf(`CREATE OR REPLACE TRIGGER %s
+BEFORE INSERT ON %s
+FOR EACH ROW
+WHEN (NEW.%s IS NULL)
+BEGIN
+    :NEW.%s := %s.NEXTVAL;
+END;`, trigger, t.Name, id.Name, id.Name, seq)},
+		)
+	}
+
+	for _, i := range t.Indexes {
+		if !i.AppliesTo("oracle") {
+			continue
+		}
+		unique := ""
+		if i.Unique {
+			unique = "UNIQUE "
+		}
+		name := t.IndexName(i)
+		stmts = append(stmts, Statement{Object: "INDEX", Name: name, SQL: fmt.Sprintf(
+			"CREATE %sINDEX %s ON %s (%s)", unique, name, t.Name, strings.Join(i.Columns, ", "))})
+	}
+	return stmts
+}
+
+// oracleBound is the literal for r's upper bound in the partition column's type
+func oracleBound(t *Table, r Range) string {
+	if t.Column(t.Partition.Column).Type.Kind != Date {
+		return fmt.Sprintf("TIMESTAMP '%s 00:00:00'", r.To.Format(time.DateOnly))
+	}
+	return fmt.Sprintf("DATE '%s'", r.To.Format(time.DateOnly))
+}
+
+func (oracleDialect) ListPartitions(t *Table) string {
+	return fmt.Sprintf("SELECT partition_name FROM user_tab_partitions WHERE table_name = '%s'", strings.ToUpper(t.Name))
+}
+
+// AddPartitions appends ranges above the highest partition, one statement
+// each: SPLIT PARTITION carves them out of p_future when it exists,
+// otherwise ADD PARTITION extends the table
+func (oracleDialect) AddPartitions(t *Table, existing []string, from, to time.Time) []Statement {
+	future := slices.ContainsFunc(existing, func(name string) bool { return strings.EqualFold(name, FutureName) })
+	var stmts []Statement
+	for _, r := range t.Partition.appendAfter(existing, from, to) {
+		alter := fmt.Sprintf("ALTER TABLE %s ADD PARTITION %s VALUES LESS THAN (%s)", t.Name, r.ShortName(), oracleBound(t, r))
+		if future {
+			alter = fmt.Sprintf("ALTER TABLE %s SPLIT PARTITION %s AT (%s) INTO (PARTITION %s, PARTITION %s)",
+				t.Name, FutureName, oracleBound(t, r), r.ShortName(), FutureName)
+		}
+		stmts = append(stmts, Statement{Object: "PARTITION", Name: r.ShortName(), SQL: alter})
+	}
+	return stmts
+}
+
+func (oracleDialect) Insert(t *Table) string {
+	return insert(t, func(i int) string { return fmt.Sprintf(":%d", i) })
+}
+
+func (oracleDialect) SelectKeys(t *Table, limit int) string {
+	return orderedKeys(t) + fmt.Sprintf(" FETCH FIRST %d ROWS ONLY", limit)
+}
+
+func (oracleDialect) Update(t *Table) string {
+	return update(t, func(i int) string { return fmt.Sprintf(":%d", i) })
+}
+
+func (oracleDialect) Delete(t *Table) string {
+	return deleteByKey(t, func(i int) string { return fmt.Sprintf(":%d", i) })
+}
+
+func (oracleDialect) Bind(c *Column, v any) any {
+	if b, ok := v.(bool); ok {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	return binaryBind(c, v)
+}
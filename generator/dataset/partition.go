@@ -0,0 +1,155 @@
+package dataset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Partition is range partitioning on a date or timestamp column, split into
+// month or quarter ranges between From (inclusive) and To (exclusive)
+type Partition struct {
+	Column   string `yaml:"column"`
+	Interval string `yaml:"interval"` // "month" or "quarter"
+	From     string `yaml:"from"`     // YYYY-MM-DD, aligned to the interval
+	To       string `yaml:"to"`
+	Future   bool   `yaml:"future"` // add a catch-all partition for rows on or after To
+}
+
+// Range is one partition's [From, To) bounds
+type Range struct {
+	Label string // 2025_08 for months, 2025_q3 for quarters
+	From  time.Time
+	To    time.Time
+}
+
+// ShortName is the label in the p202508 / p2025q3 form used by MySQL and Oracle
+func (r Range) ShortName() string {
+	return "p" + strings.ReplaceAll(r.Label, "_", "")
+}
+
+// FutureName is the name of the catch-all partition on MySQL and Oracle
+const FutureName = "p_future"
+
+func (p *Partition) validate(t *Table) error {
+	c := t.Column(p.Column)
+	if c == nil {
+		return fmt.Errorf("unknown column %s", p.Column)
+	}
+	if !c.Type.IsTime() {
+		return fmt.Errorf("column %s must be a date or timestamp, got %s", p.Column, c.Type)
+	}
+	if p.Interval != "month" && p.Interval != "quarter" {
+		return fmt.Errorf("interval must be month or quarter, got %q", p.Interval)
+	}
+
+	from, to, err := p.bounds()
+	if err != nil {
+		return err
+	}
+	if !from.Before(to) {
+		return fmt.Errorf("from %s must be before to %s", p.From, p.To)
+	}
+	step := p.months()
+	for _, d := range []time.Time{from, to} {
+		if d.Day() != 1 || (int(d.Month())-1)%step != 0 {
+			return fmt.Errorf("bound %s is not aligned to a %s", d.Format(time.DateOnly), p.Interval)
+		}
+	}
+	return nil
+}
+
+func (p *Partition) bounds() (time.Time, time.Time, error) {
+	from, err := time.Parse(time.DateOnly, p.From)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+	to, err := time.Parse(time.DateOnly, p.To)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	return from, to, nil
+}
+
+func (p *Partition) months() int {
+	if p.Interval == "quarter" {
+		return 3
+	}
+	return 1
+}
+
+// Ranges lists the partitions between From and To in order
+func (p *Partition) Ranges() []Range {
+	from, to, err := p.bounds()
+	if err != nil {
+		return nil
+	}
+
+	var ranges []Range
+	for start := from; start.Before(to); start = start.AddDate(0, p.months(), 0) {
+		ranges = append(ranges, p.rangeAt(start))
+	}
+	return ranges
+}
+
+// rangeAt returns the partition range starting at the aligned date start
+func (p *Partition) rangeAt(start time.Time) Range {
+	label := start.Format("2006_01")
+	if p.Interval == "quarter" {
+		label = fmt.Sprintf("%d_q%d", start.Year(), (int(start.Month())-1)/3+1)
+	}
+	return Range{Label: label, From: start, To: start.AddDate(0, p.months(), 0)}
+}
+
+// Cover lists the ranges holding every instant from from to to, inclusive
+func (p *Partition) Cover(from, to time.Time) []Range {
+	from, to = from.UTC(), to.UTC()
+	month := int(from.Month()) - (int(from.Month())-1)%p.months()
+	var ranges []Range
+	for start := time.Date(from.Year(), time.Month(month), 1, 0, 0, 0, 0, time.UTC); !start.After(to); start = start.AddDate(0, p.months(), 0) {
+		ranges = append(ranges, p.rangeAt(start))
+	}
+	return ranges
+}
+
+// ParseShortName reverses Range.ShortName, ignoring case since Oracle
+// reports partition names in upper case
+func (p *Partition) ParseShortName(name string) (Range, bool) {
+	name = strings.ToLower(name)
+	var year, n int
+	if p.Interval == "quarter" {
+		if _, err := fmt.Sscanf(name, "p%4dq%1d", &year, &n); err != nil || n < 1 || n > 4 {
+			return Range{}, false
+		}
+		n = (n-1)*3 + 1
+	} else if _, err := fmt.Sscanf(name, "p%4d%2d", &year, &n); err != nil || n < 1 || n > 12 {
+		return Range{}, false
+	}
+	r := p.rangeAt(time.Date(year, time.Month(n), 1, 0, 0, 0, 0, time.UTC))
+	if r.ShortName() != name {
+		return Range{}, false
+	}
+	return r, true
+}
+
+// appendAfter lists the ranges that extend partitions parsed from the
+// existing names up to to, for dialects that can only add ranges above the
+// highest one. Rows before the lowest partition already land in it, since
+// its only bound is an upper one.
+func (p *Partition) appendAfter(existing []string, from, to time.Time) []Range {
+	var top time.Time
+	for _, name := range existing {
+		if r, ok := p.ParseShortName(name); ok && r.To.After(top) {
+			top = r.To
+		}
+	}
+	if !top.IsZero() {
+		// Start at the current top even when from is later, so the
+		// partition names keep matching the rows they hold
+		from = top
+	}
+	if from.After(to) {
+		return nil
+	}
+	return p.Cover(from, to)
+}
@@ -0,0 +1,183 @@
+package dataset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+type postgresDialect struct{}
+
+// Postgres renders tables as PostgreSQL DDL with declarative partitioning
+var Postgres Dialect = postgresDialect{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+var postgresColumns = columnStyle{
+	typeName:    postgresType,
+	now:         func(*Column) string { return "CURRENT_TIMESTAMP" },
+	today:       func(*Column) string { return "CURRENT_DATE" },
+	trueFalse:   [2]string{"TRUE", "FALSE"},
+	foreignKeys: true,
+}
+
+func postgresType(c *Column) string {
+	t := c.Type
+	if c.Identity {
+		switch t.Kind {
+		case SmallInt, TinyInt:
+			return "SMALLSERIAL"
+		case Int:
+			return "SERIAL"
+		default:
+			return "BIGSERIAL"
+		}
+	}
+	switch t.Kind {
+	case TinyInt, SmallInt:
+		return "SMALLINT"
+	case Int:
+		return "INTEGER"
+	case BigInt:
+		return "BIGINT"
+	case Decimal:
+		return fmt.Sprintf("DECIMAL(%d, %d)", t.Precision, t.Scale)
+	case Varchar:
+		return fmt.Sprintf("VARCHAR(%d)", t.Length)
+	case Char:
+		return fmt.Sprintf("CHAR(%d)", t.Length)
+	case Bool:
+		return "BOOLEAN"
+	case Date:
+		return "DATE"
+	case Timestamp:
+		return "TIMESTAMP"
+	case TimestampTZ:
+		return "TIMESTAMPTZ"
+	case UUID:
+		return "UUID"
+	case IP:
+		return "INET"
+	case JSON:
+		return "JSONB"
+	case Bytes:
+		return "BYTEA"
+	default:
+		return "TEXT"
+	}
+}
+
+func (postgresDialect) CreateTable(t *Table) []Statement {
+	defs := append(postgresColumns.columnDefs(t), tableConstraints(t, "PRIMARY KEY")...)
+	create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s %s", t.Name, createTableBody(defs))
+	if p := t.Partition; p != nil {
+		create += fmt.Sprintf(" PARTITION BY RANGE (%s)", p.Column)
+	}
+	var stmts []Statement
+	if t.Partition != nil {
+		stmts = append(stmts, postgresRequirePartitioned(t))
+	}
+	stmts = append(stmts, Statement{Object: "TABLE", Name: t.Name, SQL: create}, postgresAddColumns(t))
+
+	if p := t.Partition; p != nil {
+		for _, r := range p.Ranges() {
+			stmts = append(stmts, postgresPartition(t, r))
+		}
+		if p.Future {
+			name := t.Name + "_default"
+			stmts = append(stmts, Statement{Object: "TABLE", Name: name, SQL: fmt.Sprintf(
+				"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s DEFAULT", name, t.Name)})
+		}
+	}
+
+	for _, i := range t.Indexes {
+		if !i.AppliesTo("postgres") {
+			continue
+		}
+		unique := ""
+		if i.Unique {
+			unique = "UNIQUE "
+		}
+		name := t.IndexName(i)
+		stmts = append(stmts, Statement{Object: "INDEX", Name: name, SQL: fmt.Sprintf(
+			"CREATE %sINDEX IF NOT EXISTS %s ON %s(%s)", unique, name, t.Name, strings.Join(i.Columns, ", "))})
+	}
+	return stmts
+}
+
+// postgresRequirePartitioned fails with a clear message when a table the
+// spec partitions already exists unpartitioned, as tables created before
+// the spec partitioned them do. CREATE TABLE IF NOT EXISTS would keep it,
+// and partitions and inserts would fail later on.
+func postgresRequirePartitioned(t *Table) Statement {
+	return Statement{Object: "TABLE", Name: t.Name, SQL: fmt.Sprintf(`DO $$
+BEGIN
+  IF (SELECT relkind FROM pg_class WHERE oid = to_regclass('%[1]s')) = 'r' THEN
+    RAISE EXCEPTION '%[1]s exists but is not partitioned by %[2]s; drop it, or rename it and copy its rows into the new table, then rerun';
+  END IF;
+END $$`, t.Name, t.Partition.Column)}
+}
+
+// postgresAddColumns adds the columns a table created from an older spec
+// lacks. ADD COLUMN IF NOT EXISTS would still add the constraints of a
+// column that exists on some versions, so each column is looked up first.
+func postgresAddColumns(t *Table) Statement {
+	var b strings.Builder
+	b.WriteString("DO $$\nBEGIN\n")
+	for i, def := range postgresColumns.columnDefs(t) {
+		fmt.Fprintf(&b, `  IF NOT EXISTS (SELECT 1 FROM pg_attribute WHERE attrelid = '%s'::regclass AND attname = '%s' AND NOT attisdropped) THEN
+    ALTER TABLE %s ADD COLUMN %s;
+  END IF;
+`, t.Name, t.Columns[i].Name, t.Name, def)
+	}
+	b.WriteString("END $$")
+	return Statement{Object: "COLUMNS", Name: t.Name, SQL: b.String()}
+}
+
+// postgresPartition creates the child table holding r
+func postgresPartition(t *Table, r Range) Statement {
+	name := t.Name + "_" + r.Label
+	return Statement{Object: "TABLE", Name: name, SQL: fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+		name, t.Name, r.From.Format(time.DateOnly), r.To.Format(time.DateOnly))}
+}
+
+func (postgresDialect) ListPartitions(t *Table) string {
+	return fmt.Sprintf(`SELECT c.relname FROM pg_inherits i
+JOIN pg_class c ON c.oid = i.inhrelid
+WHERE i.inhparent = '%s'::regclass`, t.Name)
+}
+
+// AddPartitions creates every missing child table in the window. Ranges
+// can be attached anywhere, but one whose rows already sit in the default
+// partition fails until those rows are moved.
+func (postgresDialect) AddPartitions(t *Table, existing []string, from, to time.Time) []Statement {
+	var stmts []Statement
+	for _, r := range t.Partition.Cover(from, to) {
+		if !slices.Contains(existing, t.Name+"_"+r.Label) {
+			stmts = append(stmts, postgresPartition(t, r))
+		}
+	}
+	return stmts
+}
+
+func (postgresDialect) Insert(t *Table) string {
+	return insert(t, func(i int) string { return fmt.Sprintf("$%d", i) })
+}
+
+func (postgresDialect) SelectKeys(t *Table, limit int) string {
+	return orderedKeys(t) + fmt.Sprintf(" LIMIT %d", limit)
+}
+
+func (postgresDialect) Update(t *Table) string {
+	return update(t, func(i int) string { return fmt.Sprintf("$%d", i) })
+}
+
+func (postgresDialect) Delete(t *Table) string {
+	return deleteByKey(t, func(i int) string { return fmt.Sprintf("$%d", i) })
+}
+
+func (postgresDialect) Bind(c *Column, v any) any {
+	return textBind(c, v)
+}
@@ -0,0 +1,252 @@
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Statement is one DDL statement and the database object it creates
+type Statement struct {
+	Object string // TABLE, INDEX, SEQUENCE, TRIGGER, PARTITION FUNCTION, ...
+	Name   string
+	SQL    string
+}
+
+// Dialect renders tables for one SQL database
+type Dialect interface {
+	// Name is the key used by Index.Dialects and the render command
+	Name() string
+	// CreateTable returns the statements creating t with its partitions,
+	// indexes and supporting objects. Postgres, MySQL and SQL Server
+	// statements are idempotent; Oracle has no IF NOT EXISTS, so callers
+	// check Object/Name before running each statement.
+	CreateTable(t *Table) []Statement
+	// Insert returns a single-row INSERT of t's generated columns
+	Insert(t *Table) string
+	// SelectKeys returns a query for the primary keys of up to limit of
+	// the newest rows of t
+	SelectKeys(t *Table, limit int) string
+	// Update rewrites t's mutable columns of one row, binding the mutable
+	// values first and the primary key after them
+	Update(t *Table) string
+	// Delete removes one row of t by primary key
+	Delete(t *Table) string
+	// ListPartitions returns a query for the names of t's partitions, one
+	// per row. SQL Server partitions have no names, so there it lists the
+	// boundary dates of t's partition function as YYYY-MM-DD instead.
+	ListPartitions(t *Table) string
+	// AddPartitions returns the statements that extend t's partitions to
+	// cover from through to, given what ListPartitions returned
+	AddPartitions(t *Table, existing []string, from, to time.Time) []Statement
+	// Bind converts a canonical Row value for c into a driver argument
+	Bind(c *Column, v any) any
+}
+
+// Dialects are the SQL renderers by name. MariaDB shares the MySQL dialect.
+var Dialects = map[string]Dialect{
+	"postgres": Postgres,
+	"mysql":    MySQL,
+	"mssql":    MSSQL,
+	"oracle":   Oracle,
+}
+
+// DialectNames lists the keys of Dialects
+func DialectNames() []string {
+	names := make([]string, 0, len(Dialects))
+	for name := range Dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// columnStyle holds the per-dialect spelling used by columnDefs
+type columnStyle struct {
+	typeName    func(c *Column) string // includes the identity clause
+	now, today  func(c *Column) string
+	trueFalse   [2]string
+	foreignKeys bool
+}
+
+// columnDefs renders each column as
+// name TYPE [DEFAULT x] [NOT NULL] [UNIQUE] [CHECK (...)] [REFERENCES t(c) ...]
+func (s columnStyle) columnDefs(t *Table) []string {
+	defs := make([]string, 0, len(t.Columns))
+	for _, c := range t.Columns {
+		var b strings.Builder
+		b.WriteString(c.Name + " " + s.typeName(c))
+		if c.Default != nil {
+			b.WriteString(" DEFAULT " + s.defaultExpr(c))
+		}
+		if c.NotNull {
+			b.WriteString(" NOT NULL")
+		}
+		if c.Unique {
+			b.WriteString(" UNIQUE")
+		}
+		if c.Check != nil {
+			b.WriteString(" " + checkClause(c))
+		}
+		if ref := c.References; ref != nil && s.foreignKeys {
+			fmt.Fprintf(&b, " REFERENCES %s(%s)", ref.Table, ref.Column)
+			if ref.OnDelete != "" {
+				b.WriteString(" ON DELETE " + strings.ToUpper(ref.OnDelete))
+			}
+		}
+		defs = append(defs, b.String())
+	}
+	return defs
+}
+
+func (s columnStyle) defaultExpr(c *Column) string {
+	switch c.Default.Func {
+	case "now":
+		return s.now(c)
+	case "today":
+		return s.today(c)
+	}
+	switch v := c.Default.Literal.(type) {
+	case bool:
+		if v {
+			return s.trueFalse[0]
+		}
+		return s.trueFalse[1]
+	case int:
+		return fmt.Sprint(v)
+	case float64:
+		return formatNumber(v)
+	default:
+		return quoteString(fmt.Sprint(v))
+	}
+}
+
+// tableConstraints renders the primary key and multi-column unique keys
+func tableConstraints(t *Table, primaryKey string) []string {
+	var defs []string
+	if len(t.PrimaryKey) > 0 {
+		defs = append(defs, fmt.Sprintf("%s (%s)", primaryKey, strings.Join(t.PrimaryKey, ", ")))
+	}
+	for _, key := range t.Unique {
+		defs = append(defs, fmt.Sprintf("UNIQUE (%s)", strings.Join(key, ", ")))
+	}
+	return defs
+}
+
+// createTableBody joins column definitions and constraints into "(\n ...\n)"
+func createTableBody(defs []string) string {
+	return "(\n    " + strings.Join(defs, ",\n    ") + "\n)"
+}
+
+// insert renders INSERT INTO t (cols) VALUES (placeholders)
+func insert(t *Table, placeholder func(i int) string) string {
+	cols := t.Generated()
+	names := make([]string, len(cols))
+	marks := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+		marks[i] = placeholder(i + 1)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		t.Name, strings.Join(names, ", "), strings.Join(marks, ", "))
+}
+
+// orderedKeys selects t's primary key, newest rows first, leaving the
+// row limit to the dialect
+func orderedKeys(t *Table) string {
+	desc := make([]string, len(t.PrimaryKey))
+	for i, name := range t.PrimaryKey {
+		desc[i] = name + " DESC"
+	}
+	return fmt.Sprintf("SELECT %s FROM %s ORDER BY %s",
+		strings.Join(t.PrimaryKey, ", "), t.Name, strings.Join(desc, ", "))
+}
+
+// keyMatch renders "pk1 = ? AND pk2 = ?" with placeholders numbered from first
+func keyMatch(t *Table, placeholder func(i int) string, first int) string {
+	terms := make([]string, len(t.PrimaryKey))
+	for i, name := range t.PrimaryKey {
+		terms[i] = name + " = " + placeholder(first+i)
+	}
+	return strings.Join(terms, " AND ")
+}
+
+func update(t *Table, placeholder func(i int) string) string {
+	cols := t.Mutable()
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = c.Name + " = " + placeholder(i+1)
+	}
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		t.Name, strings.Join(sets, ", "), keyMatch(t, placeholder, len(cols)+1))
+}
+
+func deleteByKey(t *Table, placeholder func(i int) string) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE %s", t.Name, keyMatch(t, placeholder, 1))
+}
+
+// UUIDString formats a uuid value in canonical 8-4-4-4-12 form
+func UUIDString(id [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+// FormatValue renders a canonical value as text: uuids in canonical form,
+// dates as YYYY-MM-DD, timestamps as RFC 3339 and json marshalled. It is
+// the encoding used wherever a store has no native type for the column.
+func FormatValue(c *Column, v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case [16]byte:
+		return UUIDString(v)
+	case netip.Addr:
+		return v.String()
+	case time.Time:
+		if c.Type.Kind == Date {
+			return v.Format(time.DateOnly)
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return fmt.Sprintf("%x", v)
+	}
+	if c.Type.Kind == JSON {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// textBind is the Bind shared by drivers that take uuids, addresses and
+// json as strings
+func textBind(c *Column, v any) any {
+	switch v.(type) {
+	case [16]byte, netip.Addr:
+		return FormatValue(c, v)
+	}
+	if c.Type.Kind == JSON && v != nil {
+		return FormatValue(c, v)
+	}
+	return v
+}
+
+// binaryBind is the Bind shared by drivers that store uuids and addresses
+// as raw bytes. Addresses use the INET6_ATON packing: 4 bytes for IPv4,
+// 16 for IPv6.
+func binaryBind(c *Column, v any) any {
+	switch v := v.(type) {
+	case [16]byte:
+		return v[:]
+	case netip.Addr:
+		return v.AsSlice()
+	}
+	if c.Type.Kind == JSON && v != nil {
+		return FormatValue(c, v)
+	}
+	return v
+}
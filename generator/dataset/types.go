@@ -0,0 +1,122 @@
+package dataset
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is a canonical column type shared by every dialect
+type Kind string
+
+const (
+	TinyInt     Kind = "tinyint"
+	SmallInt    Kind = "smallint"
+	Int         Kind = "int"
+	BigInt      Kind = "bigint"
+	Decimal     Kind = "decimal"
+	Varchar     Kind = "varchar"
+	Char        Kind = "char"
+	Text        Kind = "text"
+	Bool        Kind = "bool"
+	Date        Kind = "date"
+	Timestamp   Kind = "timestamp"   // wall clock time without zone
+	TimestampTZ Kind = "timestamptz" // instant, stored as UTC where the dialect has no zone
+	UUID        Kind = "uuid"
+	IP          Kind = "ip" // IPv4 or IPv6 address
+	JSON        Kind = "json"
+	Bytes       Kind = "bytes"
+)
+
+// Type is a canonical type with its size parameters, written in specs as
+// "bigint", "varchar(100)" or "decimal(20,4)"
+type Type struct {
+	Kind      Kind
+	Length    int // varchar, char and bytes length; 0 means unbounded for bytes
+	Precision int // decimal precision
+	Scale     int // decimal scale
+}
+
+var typePattern = regexp.MustCompile(`^([a-z]+)(?:\((\d+)(?:,\s*(\d+))?\))?$`)
+
+// ParseType parses the spec spelling of a type
+func ParseType(s string) (Type, error) {
+	m := typePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return Type{}, fmt.Errorf("invalid type %q", s)
+	}
+
+	t := Type{Kind: Kind(m[1])}
+	size, _ := strconv.Atoi(m[2])
+	scale, _ := strconv.Atoi(m[3])
+
+	switch t.Kind {
+	case Varchar, Char:
+		if size <= 0 || m[3] != "" {
+			return Type{}, fmt.Errorf("type %q needs a single length, e.g. %s(50)", s, t.Kind)
+		}
+		t.Length = size
+	case Bytes:
+		if m[3] != "" {
+			return Type{}, fmt.Errorf("type %q takes at most a length", s)
+		}
+		t.Length = size
+	case Decimal:
+		if size <= 0 || scale > size {
+			return Type{}, fmt.Errorf("type %q needs precision and scale, e.g. decimal(20,4)", s)
+		}
+		t.Precision, t.Scale = size, scale
+	case TinyInt, SmallInt, Int, BigInt, Text, Bool, Date, Timestamp, TimestampTZ, UUID, IP, JSON:
+		if m[2] != "" {
+			return Type{}, fmt.Errorf("type %q takes no size", s)
+		}
+	default:
+		return Type{}, fmt.Errorf("unknown type %q", s)
+	}
+	return t, nil
+}
+
+// String formats t in spec spelling
+func (t Type) String() string {
+	switch t.Kind {
+	case Varchar, Char:
+		return fmt.Sprintf("%s(%d)", t.Kind, t.Length)
+	case Bytes:
+		if t.Length > 0 {
+			return fmt.Sprintf("%s(%d)", t.Kind, t.Length)
+		}
+	case Decimal:
+		return fmt.Sprintf("%s(%d,%d)", t.Kind, t.Precision, t.Scale)
+	}
+	return string(t.Kind)
+}
+
+// IsInteger reports whether t is one of the integer kinds
+func (t Type) IsInteger() bool {
+	switch t.Kind {
+	case TinyInt, SmallInt, Int, BigInt:
+		return true
+	}
+	return false
+}
+
+// IsTime reports whether t holds a date or timestamp
+func (t Type) IsTime() bool {
+	return t.Kind == Date || t.Kind == Timestamp || t.Kind == TimestampTZ
+}
+
+func (t *Type) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := ParseType(s)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*t = parsed
+	return nil
+}
@@ -0,0 +1,223 @@
+// Package distribution provides the skewed value distributions columns and
+// seeders draw numbers from: Zipf for hot keys, log-normal for latencies
+// and amounts, Poisson for counts and a clamped normal.
+package distribution
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"datagenerator/generator/rng"
+)
+
+// Distribution draws numbers. It holds no state, so one Distribution can
+// serve every goroutine.
+type Distribution interface {
+	Sample(src *rng.Source) float64
+}
+
+// Names lists the supported distributions
+func Names() []string {
+	return []string{"uniform", "zipf", "lognormal", "poisson", "normal"}
+}
+
+// Params selects a distribution and its parameters. Generators embed it
+// inline, so a spec reads {kind: int, min: 0, max: 99999, dist: zipf, s: 1.2}.
+type Params struct {
+	Dist   string  `yaml:"dist"`   // one of Names(), default uniform
+	S      float64 `yaml:"s"`      // zipf exponent, > 1
+	V      float64 `yaml:"v"`      // zipf offset, >= 1
+	Mu     float64 `yaml:"mu"`     // lognormal mean of the log
+	Sigma  float64 `yaml:"sigma"`  // lognormal standard deviation of the log
+	Lambda float64 `yaml:"lambda"` // poisson mean
+	Mean   float64 `yaml:"mean"`   // normal mean
+	StdDev float64 `yaml:"stddev"` // normal standard deviation
+}
+
+// Uniform reports whether p leaves values evenly spread
+func (p Params) Uniform() bool {
+	return p.Dist == "" || p.Dist == "uniform"
+}
+
+// New builds the distribution for values in [min, max]. Unbounded
+// distributions are clamped to the range.
+func (p Params) New(min, max float64) (Distribution, error) {
+	if max < min {
+		return nil, fmt.Errorf("max %v is below min %v", max, min)
+	}
+	var d Distribution
+	switch p.Dist {
+	case "", "uniform":
+		return uniform{min, max}, nil
+	case "zipf":
+		v := p.V
+		if v == 0 {
+			v = 1
+		}
+		if p.S <= 1 || v < 1 {
+			return nil, fmt.Errorf("zipf needs s > 1 and v >= 1, got s=%v v=%v", p.S, v)
+		}
+		return Zipf(p.S, v, min, max), nil
+	case "lognormal":
+		if p.Sigma <= 0 {
+			return nil, fmt.Errorf("lognormal needs sigma > 0, got %v", p.Sigma)
+		}
+		d = LogNormal(p.Mu, p.Sigma)
+	case "poisson":
+		if p.Lambda <= 0 {
+			return nil, fmt.Errorf("poisson needs lambda > 0, got %v", p.Lambda)
+		}
+		d = Poisson(p.Lambda)
+	case "normal":
+		if p.StdDev <= 0 {
+			return nil, fmt.Errorf("normal needs stddev > 0, got %v", p.StdDev)
+		}
+		d = Normal(p.Mean, p.StdDev)
+	default:
+		return nil, fmt.Errorf("unknown dist %q (valid: %s)", p.Dist, strings.Join(Names(), ", "))
+	}
+	return Clamp(d, min, max), nil
+}
+
+// Index builds the distribution of an index into a list of n items, so
+// that every index, the last included, can be drawn: Zipf ranks 0 to n-1
+// when p is zipf, and any other distribution over [0, n) rounded down
+func (p Params) Index(n int) (Distribution, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("need at least one item to index, got %d", n)
+	}
+	if p.Uniform() {
+		return uniformIndex(n), nil
+	}
+	if p.Dist == "zipf" {
+		return p.New(0, float64(n-1))
+	}
+	d, err := p.New(0, float64(n))
+	if err != nil {
+		return nil, err
+	}
+	return floored{d, float64(n - 1)}, nil
+}
+
+type uniformIndex int
+
+func (n uniformIndex) Sample(src *rng.Source) float64 {
+	return float64(src.Intn(int(n)))
+}
+
+type floored struct {
+	d    Distribution
+	last float64
+}
+
+func (f floored) Sample(src *rng.Source) float64 {
+	return math.Min(f.last, math.Floor(f.d.Sample(src)))
+}
+
+type uniform struct{ min, max float64 }
+
+func (u uniform) Sample(src *rng.Source) float64 {
+	return u.min + src.Float64()*(u.max-u.min)
+}
+
+type clamped struct {
+	d        Distribution
+	min, max float64
+}
+
+// Clamp limits d's samples to [min, max]
+func Clamp(d Distribution, min, max float64) Distribution {
+	return clamped{d, min, max}
+}
+
+func (c clamped) Sample(src *rng.Source) float64 {
+	return math.Max(c.min, math.Min(c.max, c.d.Sample(src)))
+}
+
+type logNormal struct{ mu, sigma float64 }
+
+// LogNormal draws e^X for X normal with mean mu and deviation sigma; the
+// median is e^mu
+func LogNormal(mu, sigma float64) Distribution {
+	return logNormal{mu, sigma}
+}
+
+func (l logNormal) Sample(src *rng.Source) float64 {
+	return math.Exp(l.mu + l.sigma*src.NormFloat64())
+}
+
+type normal struct{ mean, stddev float64 }
+
+// Normal draws from a normal distribution
+func Normal(mean, stddev float64) Distribution {
+	return normal{mean, stddev}
+}
+
+func (n normal) Sample(src *rng.Source) float64 {
+	return n.mean + n.stddev*src.NormFloat64()
+}
+
+type poisson struct{ lambda, limit float64 }
+
+// Poisson draws event counts with mean lambda
+func Poisson(lambda float64) Distribution {
+	return poisson{lambda, math.Exp(-lambda)}
+}
+
+// poissonNormalFrom is the mean above which the normal approximation
+// replaces Knuth's multiplication method, whose cost grows with lambda
+const poissonNormalFrom = 30
+
+func (p poisson) Sample(src *rng.Source) float64 {
+	if p.lambda >= poissonNormalFrom {
+		return math.Max(0, math.Round(p.lambda+math.Sqrt(p.lambda)*src.NormFloat64()))
+	}
+	k, product := 0.0, src.Float64()
+	for product > p.limit {
+		k++
+		product *= src.Float64()
+	}
+	return k
+}
+
+// zipf samples ranks 0..imax with P(k) proportional to (v+k)^-s by
+// rejection-inversion (Hörmann and Derflinger), the method math/rand uses,
+// in constant time whatever the range
+type zipf struct {
+	min, imax               float64
+	v, q, s                 float64
+	oneMinusQ, oneMinusQInv float64
+	hxm, hx0MinusHxm        float64
+}
+
+// Zipf draws integers in [min, max], min being the most frequent and each
+// following value rarer by the power law (v+k)^-s
+func Zipf(s, v, min, max float64) Distribution {
+	z := &zipf{min: min, imax: math.Floor(max - min), v: v, q: s}
+	z.oneMinusQ = 1 - z.q
+	z.oneMinusQInv = 1 / z.oneMinusQ
+	z.hxm = z.h(z.imax + 0.5)
+	z.hx0MinusHxm = z.h(0.5) - math.Exp(math.Log(z.v)*(-z.q)) - z.hxm
+	z.s = 1 - z.hinv(z.h(1.5)-math.Exp(-z.q*math.Log(z.v+1)))
+	return z
+}
+
+func (z *zipf) h(x float64) float64 {
+	return math.Exp(z.oneMinusQ*math.Log(z.v+x)) * z.oneMinusQInv
+}
+
+func (z *zipf) hinv(x float64) float64 {
+	return math.Exp(z.oneMinusQInv*math.Log(z.oneMinusQ*x)) - z.v
+}
+
+func (z *zipf) Sample(src *rng.Source) float64 {
+	for {
+		ur := z.hxm + src.Float64()*z.hx0MinusHxm
+		x := z.hinv(ur)
+		k := math.Floor(x + 0.5)
+		if k-x <= z.s || ur >= z.h(k+0.5)-math.Exp(-math.Log(k+z.v)*z.q) {
+			return z.min + k
+		}
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"strings"
 	"time"
 
+	"datagenerator/config"
+	"datagenerator/generator/geo"
+	"datagenerator/generator/rng"
+
 	"github.com/elastic/go-elasticsearch/v8"
 )
 
@@ -113,6 +117,34 @@ type GeoPoint struct {
 	Lon float64 `json:"lon"`
 }
 
+// SetOrigin fills IPAddress and Location from the same country, an ISO
+// alpha-2 or alpha-3 code, so geo aggregations agree with the IP. v6Share
+// of the addresses are IPv6.
+func (t *TransactionAnalytics) SetOrigin(src *rng.Source, country string, v6Share float64) error {
+	c, err := geo.Lookup(country)
+	if err != nil {
+		return err
+	}
+	p := c.Point(src)
+	t.IPAddress = c.IP(src, v6Share).String()
+	t.Location = GeoPoint{Lat: p.Lat, Lon: p.Lon}
+	return nil
+}
+
+// SetRisk fills RiskScore, 0 to 100, and its RiskCategory: high from 70,
+// medium from 40 and low below
+func (t *TransactionAnalytics) SetRisk(score float64) {
+	t.RiskScore = score
+	switch {
+	case score >= 70:
+		t.RiskCategory = "high"
+	case score >= 40:
+		t.RiskCategory = "medium"
+	default:
+		t.RiskCategory = "low"
+	}
+}
+
 // ============================================================================
 // INDEX 2: CUSTOMER_360 (Customer Profile + Aggregated Stats)
 // ============================================================================
@@ -577,15 +609,12 @@ func nestedAddressField() map[string]interface{} {
 }
 
 // Create all indices
-func CreateElasticsearchSchema() error {
+func CreateElasticsearchSchema(conn config.Connection) error {
 	ctx := context.Background()
 	cfg := elasticsearch.Config{
-		Addresses: []string{
-			"http://localhost:9200",
-		},
-		// Uncomment if you have authentication
-		// Username: "elastic",
-		// Password: "your-password",
+		Addresses: conn.Addresses,
+		Username:  conn.User,
+		Password:  conn.Password,
 	}
 
 	es, err := elasticsearch.NewClient(cfg)
@@ -0,0 +1,202 @@
+// Package geo places generated traffic in a country: public IPv4 and IPv6
+// addresses from that country's ISP blocks and coordinates around its
+// cities, from an embedded table, so the IP, country and location of a
+// row always agree.
+package geo
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"datagenerator/generator/rng"
+	"datagenerator/generator/weighted"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed countries.yaml
+var countriesYAML []byte
+
+// Point is a WGS 84 coordinate in degrees
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// City is a population centre locations are drawn around
+type City struct {
+	Name   string  `yaml:"name"`
+	Lat    float64 `yaml:"lat"`
+	Lon    float64 `yaml:"lon"`
+	Weight float64 `yaml:"weight"`
+}
+
+// Country is one entry of the table. It is read-only, so one Country can
+// serve every goroutine.
+type Country struct {
+	Code   string // ISO 3166-1 alpha-2
+	Alpha3 string
+	Name   string
+
+	ipv4   []netip.Prefix
+	v4Pick *weighted.Alias // prefixes weighted by size, so addresses spread evenly
+	ipv6   []netip.Prefix
+	cities *weighted.Choice[City]
+}
+
+// jitter is the standard deviation, in degrees, of points around a city
+// centre, roughly 10km
+const jitter = 0.08
+
+var countries = mustLoad()
+
+type countrySpec struct {
+	Alpha3 string   `yaml:"alpha3"`
+	Name   string   `yaml:"name"`
+	IPv4   []string `yaml:"ipv4"`
+	IPv6   []string `yaml:"ipv6"`
+	Cities []City   `yaml:"cities"`
+}
+
+func mustLoad() map[string]*Country {
+	var specs map[string]countrySpec
+	if err := yaml.Unmarshal(countriesYAML, &specs); err != nil {
+		panic("geo: invalid countries.yaml: " + err.Error())
+	}
+	table := make(map[string]*Country, len(specs))
+	for code, s := range specs {
+		c, err := compile(code, s)
+		if err != nil {
+			panic(fmt.Sprintf("geo: countries.yaml: %s: %v", code, err))
+		}
+		table[code] = c
+	}
+	return table
+}
+
+func compile(code string, s countrySpec) (*Country, error) {
+	c := &Country{Code: code, Alpha3: s.Alpha3, Name: s.Name}
+	var sizes []float64
+	for _, p := range s.IPv4 {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil || !prefix.Addr().Is4() {
+			return nil, fmt.Errorf("invalid IPv4 prefix %q", p)
+		}
+		c.ipv4 = append(c.ipv4, prefix.Masked())
+		sizes = append(sizes, math.Exp2(float64(32-prefix.Bits())))
+	}
+	for _, p := range s.IPv6 {
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil || !prefix.Addr().Is6() {
+			return nil, fmt.Errorf("invalid IPv6 prefix %q", p)
+		}
+		c.ipv6 = append(c.ipv6, prefix.Masked())
+	}
+	if len(c.ipv6) == 0 {
+		return nil, fmt.Errorf("no IPv6 prefixes")
+	}
+	var err error
+	if c.v4Pick, err = weighted.NewAlias(sizes); err != nil {
+		return nil, fmt.Errorf("ipv4: %w", err)
+	}
+	weights := make([]float64, len(s.Cities))
+	for i, city := range s.Cities {
+		weights[i] = city.Weight
+	}
+	if c.cities, err = weighted.New(s.Cities, weights); err != nil {
+		return nil, fmt.Errorf("cities: %w", err)
+	}
+	return c, nil
+}
+
+// Codes lists the alpha-2 codes in the table
+func Codes() []string {
+	codes := make([]string, 0, len(countries))
+	for code := range countries {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Lookup finds a country by its alpha-2 or alpha-3 code, in any case
+func Lookup(code string) (*Country, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if c, ok := countries[code]; ok {
+		return c, nil
+	}
+	for _, c := range countries {
+		if c.Alpha3 == code {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown country %q (known: %s)", code, strings.Join(Codes(), ", "))
+}
+
+// IPv4 draws a public IPv4 address in the country
+func (c *Country) IPv4(src *rng.Source) netip.Addr {
+	prefix := c.ipv4[c.v4Pick.Pick(src)]
+	for {
+		addr := randomIn(src, prefix)
+		// Skip the all-zeros and all-ones host of each /24
+		if last := addr.As4()[3]; prefix.Bits() > 24 || last != 0 && last != 255 {
+			return addr
+		}
+	}
+}
+
+// IPv6 draws a public IPv6 address in the country
+func (c *Country) IPv6(src *rng.Source) netip.Addr {
+	return randomIn(src, c.ipv6[src.Intn(len(c.ipv6))])
+}
+
+// IP draws an IPv6 address with probability v6Share, IPv4 otherwise
+func (c *Country) IP(src *rng.Source, v6Share float64) netip.Addr {
+	if v6Share > 0 && src.Float64() < v6Share {
+		return c.IPv6(src)
+	}
+	return c.IPv4(src)
+}
+
+// Point draws a location near one of the country's cities, picked by
+// population
+func (c *Country) Point(src *rng.Source) Point {
+	city := c.cities.Pick(src)
+	lat := math.Max(-90, math.Min(90, city.Lat+jitter*src.NormFloat64()))
+	lon := math.Remainder(city.Lon+jitter*src.NormFloat64(), 360)
+	return Point{Lat: round(lat), Lon: round(lon)}
+}
+
+// earthRadius is the mean radius of the Earth in km
+const earthRadius = 6371.0
+
+// Distance is the great-circle distance between a and b in km
+func Distance(a, b Point) float64 {
+	rad := math.Pi / 180
+	dLat, dLon := (b.Lat-a.Lat)*rad, (b.Lon-a.Lon)*rad
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(a.Lat*rad)*math.Cos(b.Lat*rad)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// round keeps six decimals, about 10cm, as geo stores and GPS report them
+func round(deg float64) float64 {
+	return math.Round(deg*1e6) / 1e6
+}
+
+// randomIn draws an address inside prefix, keeping its network bits
+func randomIn(src *rng.Source, prefix netip.Prefix) netip.Addr {
+	addr := prefix.Addr().AsSlice()
+	host := make([]byte, len(addr))
+	src.Bytes(host)
+	for i := range addr {
+		keep := min(max(prefix.Bits()-8*i, 0), 8)
+		mask := ^byte(0xff >> keep)
+		addr[i] = addr[i]&mask | host[i]&^mask
+	}
+	a, _ := netip.AddrFromSlice(addr)
+	return a
+}
@@ -0,0 +1,34 @@
+package generator
+
+// Loader selects how SQL targets ingest rows
+type Loader string
+
+const (
+	// LoaderAuto uses the bulk path for runs of at least BulkThreshold rows
+	LoaderAuto Loader = "auto"
+	// LoaderBulk uses the backend's native bulk load: COPY for Postgres,
+	// LOAD DATA LOCAL INFILE for MySQL and MariaDB, bulk copy for SQL Server
+	// and array binding for Oracle
+	LoaderBulk Loader = "bulk"
+	// LoaderInsert uses prepared INSERTs inside one transaction per batch
+	LoaderInsert Loader = "insert"
+)
+
+// BulkThreshold is the run size from which LoaderAuto switches to bulk loading
+const BulkThreshold = 100000
+
+// LoaderNames returns the accepted --loader values
+func LoaderNames() []string {
+	return []string{string(LoaderAuto), string(LoaderBulk), string(LoaderInsert)}
+}
+
+// resolve turns LoaderAuto into a concrete loader for a run of records rows
+func (l Loader) resolve(records int) Loader {
+	if l != LoaderAuto {
+		return l
+	}
+	if records >= BulkThreshold {
+		return LoaderBulk
+	}
+	return LoaderInsert
+}
@@ -0,0 +1,198 @@
+// Package merchant draws card purchases from an embedded catalog of
+// merchants by country and ISO 18245 merchant category code, with ticket
+// sizes typical of the category in the merchant's own currency.
+package merchant
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"sort"
+
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/geo"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/weighted"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed merchants.yaml
+var merchantsYAML []byte
+
+// Category is a merchant category code and its ISO 18245 description
+type Category struct {
+	MCC         string
+	Description string
+}
+
+// Merchant is a card acceptor
+type Merchant struct {
+	Name     string
+	Category Category
+	Country  string // ISO 3166-1 alpha-2
+}
+
+// Purchase is a card payment at a merchant, in the merchant's currency
+type Purchase struct {
+	Merchant Merchant
+	Amount   float64
+	Currency string // ISO 4217
+}
+
+// Market is the merchants of one country. It is read-only, so one Market
+// can serve every goroutine.
+type Market struct {
+	Country  string // ISO 3166-1 alpha-2
+	Currency string // ISO 4217
+
+	perUSD     float64
+	decimals   int
+	categories *weighted.Choice[*category]
+}
+
+// category is a Category with the merchants of one market
+type category struct {
+	Category
+	amount    distribution.Distribution // in US dollars
+	merchants *weighted.Choice[string]
+}
+
+type categorySpec struct {
+	Description string  `yaml:"description"`
+	Weight      float64 `yaml:"weight"`
+	Median      float64 `yaml:"median"`
+	Sigma       float64 `yaml:"sigma"`
+}
+
+type countrySpec struct {
+	Currency  string              `yaml:"currency"`
+	PerUSD    float64             `yaml:"per_usd"`
+	Decimals  *int                `yaml:"decimals"`
+	Merchants map[string][]string `yaml:"merchants"`
+}
+
+type catalogSpec struct {
+	Categories map[string]categorySpec `yaml:"categories"`
+	Global     map[string][]string     `yaml:"global"`
+	Countries  map[string]countrySpec  `yaml:"countries"`
+}
+
+var markets = mustLoad()
+
+func mustLoad() map[string]*Market {
+	var spec catalogSpec
+	if err := yaml.Unmarshal(merchantsYAML, &spec); err != nil {
+		panic("merchant: invalid merchants.yaml: " + err.Error())
+	}
+	table := make(map[string]*Market, len(spec.Countries))
+	for code, s := range spec.Countries {
+		m, err := compile(code, s, spec)
+		if err != nil {
+			panic(fmt.Sprintf("merchant: merchants.yaml: %s: %v", code, err))
+		}
+		table[code] = m
+	}
+	return table
+}
+
+func compile(code string, s countrySpec, spec catalogSpec) (*Market, error) {
+	country, err := geo.Lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	if s.Currency == "" || s.PerUSD <= 0 {
+		return nil, fmt.Errorf("needs a currency and a positive per_usd rate")
+	}
+	m := &Market{Country: country.Code, Currency: s.Currency, perUSD: s.PerUSD, decimals: 2}
+	if s.Decimals != nil {
+		m.decimals = *s.Decimals
+	}
+	for mcc := range s.Merchants {
+		if _, ok := spec.Categories[mcc]; !ok {
+			return nil, fmt.Errorf("unknown category %s", mcc)
+		}
+	}
+
+	// Sorted, so choices draw the same categories for the same seed
+	codes := make([]string, 0, len(spec.Categories))
+	for mcc := range spec.Categories {
+		codes = append(codes, mcc)
+	}
+	sort.Strings(codes)
+
+	var categories []*category
+	var weights []float64
+	for _, mcc := range codes {
+		names := append(append([]string(nil), s.Merchants[mcc]...), spec.Global[mcc]...)
+		if len(names) == 0 {
+			continue
+		}
+		c := spec.Categories[mcc]
+		merchants, err := byRank(names)
+		if err != nil {
+			return nil, fmt.Errorf("category %s: %w", mcc, err)
+		}
+		categories = append(categories, &category{
+			Category:  Category{MCC: mcc, Description: c.Description},
+			amount:    distribution.Clamp(distribution.LogNormal(math.Log(c.Median), c.Sigma), 0.5, 25000),
+			merchants: merchants,
+		})
+		weights = append(weights, c.Weight)
+	}
+	if m.categories, err = weighted.New(categories, weights); err != nil {
+		return nil, fmt.Errorf("categories: %w", err)
+	}
+	return m, nil
+}
+
+// byRank weights merchants listed most common first by 1/rank
+func byRank(names []string) (*weighted.Choice[string], error) {
+	weights := make([]float64, len(names))
+	for i := range names {
+		weights[i] = 1 / float64(i+1)
+	}
+	return weighted.New(names, weights)
+}
+
+// In finds the market of a country by its alpha-2 or alpha-3 code
+func In(code string) (*Market, error) {
+	country, err := geo.Lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := markets[country.Code]
+	if !ok {
+		return nil, fmt.Errorf("no merchants for country %s", country.Code)
+	}
+	return m, nil
+}
+
+// Codes lists the alpha-2 codes with merchants
+func Codes() []string {
+	codes := make([]string, 0, len(markets))
+	for code := range markets {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Price converts a US dollar amount to the market's currency, rounded to
+// its minor unit
+func (m *Market) Price(usd float64) float64 {
+	scale := math.Pow10(m.decimals)
+	return math.Round(usd*m.perUSD*scale) / scale
+}
+
+// Purchase draws a payment at one of the market's merchants, picking the
+// category by its share of card spend
+func (m *Market) Purchase(src *rng.Source) Purchase {
+	c := m.categories.Pick(src)
+	scale := math.Pow10(m.decimals)
+	return Purchase{
+		Merchant: Merchant{Name: c.merchants.Pick(src), Category: c.Category, Country: m.Country},
+		Amount:   math.Max(1, math.Round(c.amount.Sample(src)*m.perUSD*scale)) / scale,
+		Currency: m.Currency,
+	}
+}
@@ -4,60 +4,115 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math/rand"
-	"time"
+	"net/netip"
 
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
+
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
-type mongoDBUserActivityLog struct {
-	ID               primitive.ObjectID `bson:"_id,omitempty"`
-	UserID           int64              `bson:"user_id"`
-	SessionID        primitive.Binary   `bson:"session_id"`
-	EventType        int                `bson:"event_type"`
-	TimestampUTC     time.Time          `bson:"timestamp_utc"`
-	PartitionDate    time.Time          `bson:"partition_date"`
-	IPAddress        string             `bson:"ip_address,omitempty"`
-	UserAgentHash    int64              `bson:"user_agent_hash,omitempty"`
-	PageURLHash      int64              `bson:"page_url_hash,omitempty"`
-	ReferrerHash     int64              `bson:"referrer_hash,omitempty"`
-	CountryCode      string             `bson:"country_code,omitempty"`
-	DeviceType       int                `bson:"device_type,omitempty"`
-	ResponseTimeMs   int                `bson:"response_time_ms,omitempty"`
-	StatusCode       int                `bson:"status_code,omitempty"`
-	BytesTransferred int                `bson:"bytes_transferred,omitempty"`
+type mongoDBSink struct {
+	conn       config.Connection
+	table      *dataset.Table
+	client     *mongo.Client
+	collection *mongo.Collection
+}
+
+// NewMongoDBSink writes table rows as documents in a collection of the same name
+func NewMongoDBSink(conn config.Connection, table *dataset.Table) Sink {
+	return &mongoDBSink{conn: conn, table: table}
 }
 
-func MongoDB() {
+func (s *mongoDBSink) Name() string { return "MongoDB" }
+
+func (s *mongoDBSink) Open(ctx context.Context) error {
 	// MongoDB connection
-	ctx := context.Background()
-	clientOptions := options.Client().ApplyURI("mongodb://localhost:27017")
+	clientOptions := options.Client().ApplyURI(s.conn.URI)
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		return fmt.Errorf("failed to connect to MongoDB: %w", err)
 	}
-	defer client.Disconnect(ctx)
 
 	// Test connection
-	err = client.Ping(ctx, nil)
-	if err != nil {
-		log.Fatalf("Failed to ping MongoDB: %v", err)
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+
+	s.client = client
+	s.collection = client.Database(s.conn.Database).Collection(s.table.Name)
+	return nil
+}
+
+// EnsureSchema sets up the collection for billion-record scale
+func (s *mongoDBSink) EnsureSchema(ctx context.Context) error {
+	setupCollection(ctx, s.collection, s.table)
+	return nil
+}
+
+// WriteBatch inserts the rows, or upserts them by _id when the table has a
+// DocumentID, so a rerun replaces the documents it wrote before
+func (s *mongoDBSink) WriteBatch(ctx context.Context, rows []dataset.Row) error {
+	cols := s.table.Generated()
+	batch := make([]interface{}, 0, len(rows))
+	upserts := make([]mongo.WriteModel, 0, len(rows))
+	for _, row := range rows {
+		doc := make(bson.D, 0, len(cols)+1)
+		var id any
+		for i, c := range cols {
+			if c.Name == s.table.DocumentID {
+				id = mongoValue(row[i])
+				doc = append(doc, bson.E{Key: "_id", Value: id})
+			}
+			doc = append(doc, bson.E{Key: c.Name, Value: mongoValue(row[i])})
+		}
+		if s.table.DocumentID != "" {
+			upserts = append(upserts, mongo.NewReplaceOneModel().
+				SetFilter(bson.D{{Key: "_id", Value: id}}).SetReplacement(doc).SetUpsert(true))
+		} else {
+			batch = append(batch, doc)
+		}
 	}
 
-	db := client.Database("source_data_db")
-	collection := db.Collection("user_activity_log")
+	// Unordered for better performance
+	if len(upserts) > 0 {
+		if _, err := s.collection.BulkWrite(ctx, upserts, options.BulkWrite().SetOrdered(false)); err != nil {
+			return fmt.Errorf("batch upsert error: %w", err)
+		}
+		return nil
+	}
+	if _, err := s.collection.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false)); err != nil {
+		return fmt.Errorf("batch insert error: %w", err)
+	}
+	return nil
+}
 
-	// Setup collection for billion-record scale
-	setupCollection(ctx, collection)
+// mongoValue maps canonical values onto BSON types: uuids as binary
+// subtype 4, addresses as strings and everything else natively
+func mongoValue(v any) any {
+	switch v := v.(type) {
+	case [16]byte:
+		return primitive.Binary{Subtype: bson.TypeBinaryUUID, Data: v[:]}
+	case netip.Addr:
+		return v.String()
+	case []byte:
+		return primitive.Binary{Data: v}
+	}
+	return v
+}
 
-	// Insert records
-	insertMongoDB(ctx, collection)
+func (s *mongoDBSink) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Disconnect(context.Background())
 }
 
-func setupCollection(ctx context.Context, collection *mongo.Collection) {
+func setupCollection(ctx context.Context, collection *mongo.Collection, table *dataset.Table) {
 	fmt.Println("Setting up collection with indexes for billion-record scale...")
 
 	// Drop existing indexes (except _id) to recreate them
@@ -72,54 +127,28 @@ func setupCollection(ctx context.Context, collection *mongo.Collection) {
 		}
 	}
 
-	// Create compound indexes optimized for billion records
-	indexes := []mongo.IndexModel{
-		// Compound index for user queries with time range
-		{
-			Keys: bson.D{
-				{"user_id", 1},
-				{"timestamp_utc", 1},
-			},
-			Options: options.Index().SetName("idx_user_time").SetBackground(true),
-		},
-		// Compound index for event type with time
-		{
-			Keys: bson.D{
-				{"event_type", 1},
-				{"timestamp_utc", 1},
-			},
-			Options: options.Index().SetName("idx_event_time").SetBackground(true),
-		},
-		// Session ID index
-		{
-			Keys:    bson.D{{"session_id", 1}},
-			Options: options.Index().SetName("idx_session").SetBackground(true),
-		},
-		// Partition date index for time-based queries
-		{
-			Keys:    bson.D{{"partition_date", 1}},
-			Options: options.Index().SetName("idx_partition_date").SetBackground(true),
-		},
-		// Compound index for analytics queries
-		{
-			Keys: bson.D{
-				{"country_code", 1},
-				{"device_type", 1},
-				{"timestamp_utc", 1},
-			},
-			Options: options.Index().SetName("idx_analytics").SetBackground(true),
-		},
-		// Sparse index for IP addresses (only when present)
-		{
-			Keys:    bson.D{{"ip_address", 1}},
-			Options: options.Index().SetName("idx_ip").SetBackground(true).SetSparse(true),
-		},
+	// Indexes come from the dataset spec
+	var indexes []mongo.IndexModel
+	for _, index := range dataset.MongoIndexes(table) {
+		keys := bson.D{}
+		for _, column := range index.Columns {
+			keys = append(keys, bson.E{Key: column, Value: 1})
+		}
+		opts := options.Index().SetName(index.Name).SetBackground(true)
+		if index.Unique {
+			opts.SetUnique(true)
+		}
+		if index.Sparse {
+			opts.SetSparse(true)
+		}
+		indexes = append(indexes, mongo.IndexModel{Keys: keys, Options: opts})
 	}
 
 	// Create indexes
-	_, err = collection.Indexes().CreateMany(ctx, indexes)
-	if err != nil {
-		log.Printf("Warning: Failed to create some indexes: %v", err)
+	if len(indexes) > 0 {
+		if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+			log.Printf("Warning: Failed to create some indexes: %v", err)
+		}
 	}
 
 	// Enable sharding preparation (for billion-record scale)
@@ -131,73 +160,6 @@ func setupCollection(ctx context.Context, collection *mongo.Collection) {
 	fmt.Println("3. Implementing data retention policies")
 }
 
-func insertMongoDB(ctx context.Context, collection *mongo.Collection) {
-	totalRecords := 1000000 // Insert 1 million for testing
-	batchSize := 1000       // Optimal batch size for MongoDB
-
-	fmt.Printf("Starting insertion of %d records in batches of %d...\n", totalRecords, batchSize)
-
-	// Generate country codes for variety
-	countryCodes := []string{"US", "GB", "DE", "FR", "JP", "IN", "BR", "CA", "AU", "CN"}
-
-	var batch []interface{}
-	insertedCount := 0
-
-	for i := 0; i < totalRecords; i++ {
-		// Generate random timestamp within last 24 hours
-		timestamp := time.Now().Add(-time.Duration(rand.Intn(86400)) * time.Second)
-		partitionDate := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, timestamp.Location())
-
-		// Generate session ID as 16-byte binary
-		sessionIDBytes := make([]byte, 16)
-		rand.Read(sessionIDBytes)
-
-		record := mongoDBUserActivityLog{
-			UserID:           rand.Int63n(100000),
-			SessionID:        primitive.Binary{Data: sessionIDBytes, Subtype: 0x00},
-			EventType:        rand.Intn(5) + 1,
-			TimestampUTC:     timestamp,
-			PartitionDate:    partitionDate,
-			IPAddress:        fmt.Sprintf("192.168.%d.%d", rand.Intn(255), rand.Intn(255)),
-			UserAgentHash:    rand.Int63(),
-			PageURLHash:      rand.Int63(),
-			ReferrerHash:     rand.Int63(),
-			CountryCode:      countryCodes[rand.Intn(len(countryCodes))],
-			DeviceType:       rand.Intn(3) + 1,
-			ResponseTimeMs:   rand.Intn(5000),
-			StatusCode:       200 + rand.Intn(100), // 200-299 range
-			BytesTransferred: rand.Intn(10000),
-		}
-
-		batch = append(batch, record)
-
-		// Insert batch when it reaches batchSize or it's the last record
-		if len(batch) == batchSize || i == totalRecords-1 {
-			opts := options.InsertMany().SetOrdered(false) // Unordered for better performance
-			_, err := collection.InsertMany(ctx, batch, opts)
-			if err != nil {
-				log.Printf("Batch insert error: %v", err)
-				continue
-			}
-
-			insertedCount += len(batch)
-			batch = batch[:0] // Clear batch
-
-			if insertedCount%50000 == 0 {
-				fmt.Printf("Inserted %d records\n", insertedCount)
-			}
-		}
-	}
-
-	// Get total count in collection
-	totalCount, err := collection.CountDocuments(ctx, bson.M{})
-	if err != nil {
-		log.Printf("Failed to count documents: %v", err)
-	} else {
-		fmt.Printf("Completed: %d records inserted. Total records in collection: %d\n", insertedCount, totalCount)
-	}
-}
-
 // Helper function to create time series collection (MongoDB 5.0+)
 func CreateTimeSeriesCollection(ctx context.Context, db *mongo.Database) {
 	collectionName := "user_activity_log_timeseries"
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+)
+
+// mssqlBulkSink loads each batch through the TDS bulk load protocol using
+// the driver's CopyIn statement
+type mssqlBulkSink struct {
+	sqlSink
+	columns []string
+}
+
+// NewMSSQLBulkSink writes table rows to SQL Server using bulk copy
+func NewMSSQLBulkSink(conn config.Connection, table *dataset.Table) Sink {
+	s := &mssqlBulkSink{sqlSink: *newMSSQLSink(conn, table)}
+	s.name = "MSSQL (bulk copy)"
+	for _, c := range table.Generated() {
+		s.columns = append(s.columns, c.Name)
+	}
+	return s
+}
+
+func (s *mssqlBulkSink) WriteBatch(ctx context.Context, rows []dataset.Row) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, mssql.CopyIn(s.table.Name, mssql.BulkOptions{}, s.columns...))
+	if err != nil {
+		return fmt.Errorf("prepare bulk copy failed: %w", err)
+	}
+	defer stmt.Close()
+
+	cols := s.table.Generated()
+	args := make([]any, len(cols))
+	for _, row := range rows {
+		for i, c := range cols {
+			args[i] = bulkValue(c, row[i])
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("bulk copy row failed: %w", err)
+		}
+	}
+	// An Exec without arguments flushes the buffered rows to the server
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("bulk copy failed: %w", err)
+	}
+	return tx.Commit()
+}
+
+// bulkValue binds like the MSSQL dialect except for uuids, which bulk copy
+// only accepts as UNIQUEIDENTIFIER wire bytes
+func bulkValue(c *dataset.Column, v any) any {
+	if id, ok := v.([16]byte); ok {
+		return guidBytes(id)
+	}
+	return dataset.MSSQL.Bind(c, v)
+}
+
+// guidBytes reorders an RFC 4122 uuid into SQL Server's layout, whose first
+// three groups are little endian
+func guidBytes(id [16]byte) []byte {
+	b := id
+	b[0], b[1], b[2], b[3] = id[3], id[2], id[1], id[0]
+	b[4], b[5] = id[5], id[4]
+	b[6], b[7] = id[7], id[6]
+	return b[:]
+}
@@ -0,0 +1,224 @@
+package generator
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlMaxPlaceholders is the server limit on placeholders in one prepared statement
+const mysqlMaxPlaceholders = 65535
+
+// MySQL error numbers meaning LOAD DATA LOCAL is refused by the server
+// (ER_NOT_ALLOWED_COMMAND, ER_CLIENT_LOCAL_FILES_DISABLED)
+const (
+	errNotAllowedCommand        = 1148
+	errClientLocalFilesDisabled = 3948
+)
+
+// readerHandlers numbers the driver reader handlers registered by load sinks
+var readerHandlers atomic.Int64
+
+// mysqlLoadSink streams each batch as CSV into LOAD DATA LOCAL INFILE
+// through a reader handler registered with the driver, so no temp files are
+// written. Servers with local_infile disabled get multi-row INSERTs instead.
+type mysqlLoadSink struct {
+	sqlSink
+	handler  string    // Reader:: name registered with the driver
+	load     string    // LOAD DATA statement reading from handler
+	reader   io.Reader // CSV stream for the LOAD DATA currently running
+	fallback bool      // use multi-row INSERT
+}
+
+// NewMySQLLoadSink writes table rows to MySQL using LOAD DATA LOCAL INFILE
+func NewMySQLLoadSink(conn config.Connection, table *dataset.Table) Sink {
+	return newMySQLFamilyLoadSink("MySQL", conn, table)
+}
+
+// NewMariaDBLoadSink writes table rows to MariaDB using LOAD DATA LOCAL INFILE
+func NewMariaDBLoadSink(conn config.Connection, table *dataset.Table) Sink {
+	return newMySQLFamilyLoadSink("MariaDB", conn, table)
+}
+
+func newMySQLFamilyLoadSink(name string, conn config.Connection, table *dataset.Table) Sink {
+	s := &mysqlLoadSink{sqlSink: *newMySQLFamilySink(name, conn, table)}
+	s.name = name + " (LOAD DATA)"
+	return s
+}
+
+func (s *mysqlLoadSink) Open(ctx context.Context) error {
+	if err := s.sqlSink.Open(ctx); err != nil {
+		return err
+	}
+
+	var enabled bool
+	if err := s.db.QueryRowContext(ctx, "SELECT @@GLOBAL.local_infile").Scan(&enabled); err == nil && !enabled {
+		s.useInserts()
+		return nil
+	}
+
+	s.handler = fmt.Sprintf("%s_%d", s.table.Name, readerHandlers.Add(1))
+	mysql.RegisterReaderHandler(s.handler, func() io.Reader { return s.reader })
+	s.load = loadDataStatement(s.table, "Reader::"+s.handler)
+	return nil
+}
+
+func (s *mysqlLoadSink) useInserts() {
+	fmt.Println("local_infile is disabled on the server, falling back to multi-row INSERT")
+	s.fallback = true
+}
+
+func (s *mysqlLoadSink) WriteBatch(ctx context.Context, rows []dataset.Row) error {
+	if s.fallback {
+		return s.insertRows(ctx, rows)
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pw.CloseWithError(writeLoadData(pw, s.table, rows))
+	}()
+
+	s.reader = pr
+	_, err := s.db.ExecContext(ctx, s.load)
+	pr.Close() // unblocks the writer if the server stopped reading early
+	<-done
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) &&
+		(mysqlErr.Number == errNotAllowedCommand || mysqlErr.Number == errClientLocalFilesDisabled) {
+		s.useInserts()
+		return s.insertRows(ctx, rows)
+	}
+	if err != nil {
+		return fmt.Errorf("load data failed: %w", err)
+	}
+	return nil
+}
+
+// insertRows writes rows with as few multi-row INSERTs as the placeholder
+// limit allows, all in one transaction
+func (s *mysqlLoadSink) insertRows(ctx context.Context, rows []dataset.Row) error {
+	cols := s.table.Generated()
+	perStatement := max(1, mysqlMaxPlaceholders/len(cols))
+	tuple := ", (" + strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", ") + ")"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(rows); start += perStatement {
+		chunk := rows[start:min(start+perStatement, len(rows))]
+		query := s.dialect.Insert(s.table) + strings.Repeat(tuple, len(chunk)-1)
+		args := make([]any, 0, len(chunk)*len(cols))
+		for _, row := range chunk {
+			for i, c := range cols {
+				args = append(args, s.dialect.Bind(c, row[i]))
+			}
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *mysqlLoadSink) Close() error {
+	if s.handler != "" {
+		mysql.DeregisterReaderHandler(s.handler)
+	}
+	return s.sqlSink.Close()
+}
+
+// loadDataStatement reads CSV with every value quoted and NULL as the bare
+// word NULL. Binary columns arrive hex encoded and are UNHEXed on the way in.
+func loadDataStatement(t *dataset.Table, file string) string {
+	var targets, sets []string
+	for _, c := range t.Generated() {
+		if !loadAsHex(c) {
+			targets = append(targets, c.Name)
+			continue
+		}
+		targets = append(targets, "@"+c.Name)
+		sets = append(sets, fmt.Sprintf("%s = UNHEX(@%s)", c.Name, c.Name))
+	}
+
+	stmt := fmt.Sprintf(`LOAD DATA LOCAL INFILE '%s' INTO TABLE %s
+CHARACTER SET utf8mb4
+FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '"' ESCAPED BY ''
+LINES TERMINATED BY '\n'
+(%s)`, file, t.Name, strings.Join(targets, ", "))
+	if len(sets) > 0 {
+		stmt += "\nSET " + strings.Join(sets, ", ")
+	}
+	return stmt
+}
+
+// loadAsHex reports whether the MySQL column type is binary
+func loadAsHex(c *dataset.Column) bool {
+	switch c.Type.Kind {
+	case dataset.UUID, dataset.IP, dataset.Bytes:
+		return true
+	}
+	return false
+}
+
+// writeLoadData renders rows as the CSV loadDataStatement expects
+func writeLoadData(w io.Writer, t *dataset.Table, rows []dataset.Row) error {
+	cols := t.Generated()
+	bw := bufio.NewWriterSize(w, 64*1024)
+	for _, row := range rows {
+		for i, c := range cols {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			writeLoadDataField(bw, c, dataset.MySQL.Bind(c, row[i]))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+func writeLoadDataField(w *bufio.Writer, c *dataset.Column, v any) {
+	var text string
+	switch v := v.(type) {
+	case nil:
+		w.WriteString("NULL")
+		return
+	case []byte:
+		text = fmt.Sprintf("%x", v)
+	case bool:
+		text = "0"
+		if v {
+			text = "1"
+		}
+	case time.Time:
+		if c.Type.Kind == dataset.Date {
+			text = v.Format(time.DateOnly)
+		} else {
+			text = v.UTC().Format("2006-01-02 15:04:05.000000")
+		}
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	w.WriteByte('"')
+	w.WriteString(strings.ReplaceAll(text, `"`, `""`))
+	w.WriteByte('"')
+}
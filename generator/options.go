@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
+	"datagenerator/generator/workload"
+)
+
+const (
+	DefaultRecords   = 1000000 // Insert 1 million for testing
+	DefaultBatchSize = 1000
+)
+
+// DefaultDataset and DefaultTable are what the activity command generates
+// unless told otherwise
+const (
+	DefaultDataset = "activity"
+	DefaultTable   = "user_activity_log"
+)
+
+// ActivityOptions controls a single generation run
+type ActivityOptions struct {
+	Table      *dataset.Table // Table to create and fill
+	Records    int            // Total rows to insert
+	BatchSize  int            // Rows per transaction / pipeline / bulk request
+	Seed       int64          // Seed for the row generator
+	Now        time.Time      // Frozen clock that generated timestamps are anchored to
+	Loader     Loader         // Ingestion path for SQL targets
+	Generators int            // Goroutines generating rows
+	Writers    int            // Goroutines writing batches, each with its own connection
+	Stream     StreamProfile  // Paced streaming instead of a fixed Records count
+	Mix        workload.Mix   // Share of inserts, updates and deletes
+}
+
+// DefaultActivityOptions returns the options the generators historically ran with
+func DefaultActivityOptions() ActivityOptions {
+	return ActivityOptions{
+		Records:    DefaultRecords,
+		BatchSize:  DefaultBatchSize,
+		Now:        time.Now().UTC(),
+		Loader:     LoaderAuto,
+		Generators: 1,
+		Writers:    1,
+		Mix:        workload.InsertOnly,
+	}
+}
+
+// Targets maps a --target name to its sink constructor. Target names double
+// as config backend names.
+var Targets = map[string]func(config.Connection, *dataset.Table) Sink{
+	config.Postgres:      NewPostgresSink,
+	config.MySQL:         NewMySQLSink,
+	config.MariaDB:       NewMariaDBSink,
+	config.MSSQL:         NewMSSQLSink,
+	config.Oracle:        NewOracleSink,
+	config.Redis:         NewRedisSink,
+	config.MongoDB:       NewMongoDBSink,
+	config.Elasticsearch: NewElasticsearchSink,
+}
+
+// BulkTargets maps a --target name to a sink that uses the backend's native
+// bulk load path instead of per-row INSERTs
+var BulkTargets = map[string]func(config.Connection, *dataset.Table) Sink{
+	config.Postgres: NewPostgresCopySink,
+	config.MySQL:    NewMySQLLoadSink,
+	config.MariaDB:  NewMariaDBLoadSink,
+	config.MSSQL:    NewMSSQLBulkSink,
+	config.Oracle:   NewOracleArraySink,
+}
+
+// TargetNames returns the registered target names in a stable order
+func TargetNames() []string {
+	names := make([]string, 0, len(Targets))
+	for name := range Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunActivity generates rows for opts.Table into the sink registered for target
+func RunActivity(ctx context.Context, target string, conn config.Connection, opts ActivityOptions) error {
+	newSink, ok := Targets[target]
+	if !ok {
+		return fmt.Errorf("unknown target %q (valid: %v)", target, TargetNames())
+	}
+	if opts.Table == nil {
+		return fmt.Errorf("no table to generate")
+	}
+	if opts.Records <= 0 && !opts.Stream.Enabled() {
+		return fmt.Errorf("records must be positive, got %d", opts.Records)
+	}
+	if err := opts.Stream.validate(); err != nil {
+		return err
+	}
+	if opts.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", opts.BatchSize)
+	}
+	if opts.Generators <= 0 || opts.Writers <= 0 {
+		return fmt.Errorf("generators and writers must be positive, got %d and %d", opts.Generators, opts.Writers)
+	}
+	loader := opts.Loader.resolve(opts.Records)
+	if loader != LoaderBulk && loader != LoaderInsert {
+		return fmt.Errorf("unknown loader %q (valid: %v)", opts.Loader, LoaderNames())
+	}
+	if opts.Mix.Mutates() {
+		// Bulk loaders only append; mutation runs use per-row statements
+		if opts.Loader == LoaderBulk {
+			return fmt.Errorf("the bulk loader cannot update or delete, use --loader insert with --mix")
+		}
+		loader = LoaderInsert
+	}
+	if loader == LoaderBulk {
+		// auto quietly keeps INSERTs for targets without a bulk path
+		if newBulkSink, ok := BulkTargets[target]; ok {
+			newSink = newBulkSink
+		} else if opts.Loader == LoaderBulk {
+			return fmt.Errorf("target %q has no bulk loader", target)
+		}
+	}
+	return runSink(ctx, func() Sink { return newSink(conn, opts.Table) }, opts)
+}
@@ -0,0 +1,312 @@
+// Package person generates personal data that fits a country: romanized
+// names, E.164 phone numbers, unique emails on local providers, dates of
+// birth and postal addresses, from an embedded table keyed like the geo
+// table.
+package person
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/geo"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/weighted"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales.yaml
+var localesYAML []byte
+
+// Address is a postal address in the layout of the customer_addresses table
+type Address struct {
+	Line1      string
+	Line2      string // Empty when the address has no second line
+	City       string
+	State      string
+	PostalCode string
+	Country    string // ISO 3166-1 alpha-3
+}
+
+// Person is a generated individual
+type Person struct {
+	FirstName   string
+	LastName    string
+	Email       string
+	Phone       string // E.164
+	DateOfBirth time.Time
+	Nationality string // ISO 3166-1 alpha-3
+}
+
+// Locale generates the personal data of one country. It is read-only, so
+// one Locale can serve every goroutine.
+type Locale struct {
+	Country string // ISO 3166-1 alpha-2
+	Alpha3  string
+
+	callingCode  string
+	phones       []string
+	firstNames   *weighted.Choice[string]
+	lastNames    *weighted.Choice[string]
+	emailDomains *weighted.Choice[string]
+	streetFormat string
+	houseNumber  string
+	streets      []string
+	line2        line2Spec
+	cities       *weighted.Choice[citySpec]
+}
+
+type line2Spec struct {
+	Share  float64 `yaml:"share"`
+	Format string  `yaml:"format"`
+}
+
+type citySpec struct {
+	Name   string  `yaml:"name"`
+	State  string  `yaml:"state"`
+	Postal string  `yaml:"postal"`
+	Weight float64 `yaml:"weight"`
+}
+
+// weightedMap is a YAML mapping of value to weight that keeps the order it
+// was written in, so choices draw the same values for the same seed
+type weightedMap struct {
+	values  []string
+	weights []float64
+}
+
+func (w *weightedMap) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: want a mapping of value to weight", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var weight float64
+		if err := node.Content[i+1].Decode(&weight); err != nil {
+			return err
+		}
+		w.values = append(w.values, node.Content[i].Value)
+		w.weights = append(w.weights, weight)
+	}
+	return nil
+}
+
+type localeSpec struct {
+	CallingCode  string      `yaml:"calling_code"`
+	Phones       []string    `yaml:"phones"`
+	FirstNames   []string    `yaml:"first_names"`
+	LastNames    []string    `yaml:"last_names"`
+	EmailDomains weightedMap `yaml:"email_domains"`
+	StreetFormat string      `yaml:"street_format"`
+	HouseNumber  string      `yaml:"house_number"`
+	Streets      []string    `yaml:"streets"`
+	Line2        line2Spec   `yaml:"line2"`
+	Cities       []citySpec  `yaml:"cities"`
+}
+
+var locales = mustLoad()
+
+func mustLoad() map[string]*Locale {
+	var specs map[string]localeSpec
+	if err := yaml.Unmarshal(localesYAML, &specs); err != nil {
+		panic("person: invalid locales.yaml: " + err.Error())
+	}
+	table := make(map[string]*Locale, len(specs))
+	for code, s := range specs {
+		l, err := compile(code, s)
+		if err != nil {
+			panic(fmt.Sprintf("person: locales.yaml: %s: %v", code, err))
+		}
+		table[code] = l
+	}
+	return table
+}
+
+func compile(code string, s localeSpec) (*Locale, error) {
+	country, err := geo.Lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Phones) == 0 || len(s.Streets) == 0 {
+		return nil, fmt.Errorf("needs phones and streets")
+	}
+	l := &Locale{
+		Country:      country.Code,
+		Alpha3:       country.Alpha3,
+		callingCode:  s.CallingCode,
+		phones:       s.Phones,
+		streetFormat: s.StreetFormat,
+		houseNumber:  s.HouseNumber,
+		streets:      s.Streets,
+		line2:        s.Line2,
+	}
+	if l.firstNames, err = byRank(s.FirstNames); err != nil {
+		return nil, fmt.Errorf("first_names: %w", err)
+	}
+	if l.lastNames, err = byRank(s.LastNames); err != nil {
+		return nil, fmt.Errorf("last_names: %w", err)
+	}
+	if l.emailDomains, err = weighted.New(s.EmailDomains.values, s.EmailDomains.weights); err != nil {
+		return nil, fmt.Errorf("email_domains: %w", err)
+	}
+	weights := make([]float64, len(s.Cities))
+	for i, c := range s.Cities {
+		weights[i] = c.Weight
+	}
+	if l.cities, err = weighted.New(s.Cities, weights); err != nil {
+		return nil, fmt.Errorf("cities: %w", err)
+	}
+	return l, nil
+}
+
+// byRank weights names listed most common first by 1/rank, the rough
+// shape of real name frequencies
+func byRank(names []string) (*weighted.Choice[string], error) {
+	weights := make([]float64, len(names))
+	for i := range names {
+		weights[i] = 1 / float64(i+1)
+	}
+	return weighted.New(names, weights)
+}
+
+// For finds the locale of a country by its alpha-2 or alpha-3 code
+func For(code string) (*Locale, error) {
+	country, err := geo.Lookup(code)
+	if err != nil {
+		return nil, err
+	}
+	l, ok := locales[country.Code]
+	if !ok {
+		return nil, fmt.Errorf("no personal data for country %s", country.Code)
+	}
+	return l, nil
+}
+
+// Codes lists the alpha-2 codes with personal data
+func Codes() []string {
+	codes := make([]string, 0, len(locales))
+	for code := range locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Name draws a first and last name
+func (l *Locale) Name(src *rng.Source) (first, last string) {
+	return l.firstNames.Pick(src), l.lastNames.Pick(src)
+}
+
+// emailForms are the local parts people pick, most common first
+var emailForms = []string{"{first}.{last}", "{first}{last}", "{f}.{last}", "{first}_{last}", "{last}.{first}"}
+
+var emailFormChoice, _ = byRank(emailForms)
+
+// Email builds an address for first and last on a local provider. The
+// local part ends with id, and names fold to letters only, so distinct ids
+// always give distinct addresses.
+func (l *Locale) Email(src *rng.Source, first, last string, id int64) string {
+	f, s := fold(first), fold(last)
+	local := strings.NewReplacer("{first}", f, "{last}", s, "{f}", f[:min(1, len(f))]).
+		Replace(emailFormChoice.Pick(src))
+	return local + strconv.FormatInt(id, 10) + "@" + l.EmailDomain(src)
+}
+
+// EmailDomain draws a mail provider popular in the country
+func (l *Locale) EmailDomain(src *rng.Source) string {
+	return l.emailDomains.Pick(src)
+}
+
+// Phone draws a mobile number in E.164 form
+func (l *Locale) Phone(src *rng.Source) string {
+	return "+" + l.callingCode + Pattern(src, l.phones[src.Intn(len(l.phones))])
+}
+
+// Address draws a postal address in one of the country's cities
+func (l *Locale) Address(src *rng.Source) Address {
+	city := l.cities.Pick(src)
+	a := Address{
+		Line1: strings.NewReplacer(
+			"{number}", Pattern(src, l.houseNumber),
+			"{street}", l.streets[src.Intn(len(l.streets))],
+		).Replace(l.streetFormat),
+		City:       city.Name,
+		State:      city.State,
+		PostalCode: Pattern(src, city.Postal),
+		Country:    l.Alpha3,
+	}
+	if l.line2.Share > 0 && src.Float64() < l.line2.Share {
+		a.Line2 = Pattern(src, l.line2.Format)
+	}
+	return a
+}
+
+// Person draws a customer of this country; id makes the email unique
+func (l *Locale) Person(src *rng.Source, id int64) Person {
+	first, last := l.Name(src)
+	return Person{
+		FirstName:   first,
+		LastName:    last,
+		Email:       l.Email(src, first, last, id),
+		Phone:       l.Phone(src),
+		DateOfBirth: DateOfBirth(src),
+		Nationality: l.Alpha3,
+	}
+}
+
+// age is the age of adult customers, in years
+var age = distribution.Clamp(distribution.Normal(42, 15), 18, 90)
+
+// DateOfBirth draws the birth date of an adult, aged 18 to 90 around a
+// mean of 42, as of the source's frozen clock
+func DateOfBirth(src *rng.Source) time.Time {
+	years := age.Sample(src)
+	days := int(math.Floor(years * 365.2425))
+	now := src.Now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -days)
+}
+
+// Pattern fills # with any digit, % with a digit from 1 to 9 and ? with an
+// upper case letter
+func Pattern(src *rng.Source, p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		switch r {
+		case '#':
+			b.WriteByte('0' + byte(src.Intn(10)))
+		case '%':
+			b.WriteByte('1' + byte(src.Intn(9)))
+		case '?':
+			b.WriteByte('A' + byte(src.Intn(26)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// folds spells accented letters the way people do in addresses
+var folds = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"à", "a", "á", "a", "â", "a", "ã", "a", "å", "a",
+	"ç", "c", "è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ù", "u", "ú", "u", "û", "u",
+)
+
+// fold lowercases a name to the ASCII letters an email local part uses
+func fold(name string) string {
+	name = folds.Replace(strings.ToLower(name))
+	var b strings.Builder
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+// Package pool runs producer/consumer pipelines: generator goroutines fill a
+// bounded channel that writer goroutines drain, so a slow target applies
+// backpressure to generation instead of buffering without limit.
+package pool
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Run starts generators goroutines running produce and writers goroutines
+// running consume, connected by a channel holding at most buffer items. The
+// channel is closed once every producer has returned. The first error
+// cancels ctx for all workers and is returned.
+func Run[T any](ctx context.Context, generators, writers, buffer int,
+	produce func(ctx context.Context, worker int, out chan<- T) error,
+	consume func(ctx context.Context, worker int, in <-chan T) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	ch := make(chan T, buffer)
+
+	var producers sync.WaitGroup
+	for w := range generators {
+		producers.Add(1)
+		g.Go(func() error {
+			defer producers.Done()
+			return produce(ctx, w, ch)
+		})
+	}
+	go func() {
+		producers.Wait()
+		close(ch)
+	}()
+
+	for w := range writers {
+		g.Go(func() error { return consume(ctx, w, ch) })
+	}
+	return g.Wait()
+}
+
+// Send delivers v to out, giving up when ctx is cancelled
+func Send[T any](ctx context.Context, out chan<- T, v T) error {
+	select {
+	case out <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
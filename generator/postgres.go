@@ -1,95 +1,19 @@
 package generator
 
 import (
-	"database/sql"
-	"fmt"
-	"log"
-	"math/rand"
-	"time"
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
 
 	_ "github.com/lib/pq" // postgres driver
 )
 
-func Postgres() {
-	username := "postgres"
-	password := "postgres"
-	host := "localhost"
-	port := 5432
-	database := "source_data_db"
-	schema := "source_schema"
-
-	// Build DSN
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s search_path=%s sslmode=disable",
-		host, port, username, password, database, schema)
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Fatalf("failed to open connection: %v", err)
-	}
-	defer db.Close()
-	if err := db.Ping(); err != nil {
-		log.Fatalf("failed to ping database: %v", err)
-	}
-	createTable := `
-	CREATE TABLE IF NOT EXISTS user_activity_log (
-		id BIGINT GENERATED ALWAYS AS IDENTITY,
-		user_id BIGINT NOT NULL,
-		session_id UUID NOT NULL,
-		event_type SMALLINT NOT NULL,
-		timestamp_utc TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		ip_address INET,
-		user_agent_hash BIGINT,
-		page_url_hash BIGINT,
-		referrer_hash BIGINT,
-		country_code CHAR(2),
-		device_type SMALLINT,
-		response_time_ms INTEGER CHECK (response_time_ms >= 0 AND response_time_ms <= 65535),
-		status_code INTEGER CHECK (status_code >= 0 AND status_code <= 65535),
-		bytes_transferred BIGINT CHECK (bytes_transferred >= 0),
-		PRIMARY KEY (id)
-	);`
-	if _, err := db.Exec(createTable); err != nil {
-		log.Fatalf("failed creating table: %v", err)
-	}
-	insertPostgres(db)
-}
-
-func insertPostgres(db *sql.DB) {
-	totalRecords := 1000000 // Insert 1 million for testing
-
-	stmt, err := db.Prepare(`
-		INSERT INTO user_activity_log 
-		(user_id, session_id, event_type, timestamp_utc, ip_address, user_agent_hash, 
-		 page_url_hash, referrer_hash, country_code, device_type, response_time_ms, status_code, bytes_transferred) 
-		VALUES ($1, gen_random_uuid(), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`)
-	if err != nil {
-		log.Fatalf("prepare failed: %v", err)
-	}
-	defer stmt.Close()
-
-	for i := 0; i < totalRecords; i++ {
-		timestamp := time.Now().Add(-time.Duration(rand.Intn(86400)) * time.Second) // within last 24h
-
-		_, err := stmt.Exec(
-			rand.Int63n(100000), // user_id
-			rand.Intn(5)+1,      // event_type
-			timestamp,           // timestamp_utc
-			fmt.Sprintf("192.168.%d.%d", rand.Intn(255), rand.Intn(255)), // ip_address
-			rand.Int63(),     // user_agent_hash
-			rand.Int63(),     // page_url_hash
-			rand.Int63(),     // referrer_hash
-			"US",             // country_code
-			rand.Intn(3)+1,   // device_type
-			rand.Intn(5000),  // response_time_ms
-			200,              // status_code
-			rand.Intn(10000), // bytes_transferred
-		)
-		if err != nil {
-			log.Fatalf("insert failed: %v", err)
-		}
-
-		if i%50000 == 0 {
-			fmt.Printf("Inserted %d records\n", i)
-		}
+// NewPostgresSink writes table rows to Postgres
+func NewPostgresSink(conn config.Connection, table *dataset.Table) Sink {
+	return &sqlSink{
+		name:    "Postgres",
+		driver:  "postgres",
+		dsn:     conn.PostgresDSN(),
+		dialect: dataset.Postgres,
+		table:   table,
 	}
-	fmt.Println("✅ Completed: 1 million records inserted into Postgres user_activity_log")
 }
@@ -0,0 +1,330 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"datagenerator/generator/geo"
+	"datagenerator/generator/person"
+	"datagenerator/generator/rng"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// auditStaff is the number of back-office users of each tenant. There is
+	// no users table: user ids are tenant_id*1000 plus 1 to auditStaff, and
+	// tenant_id*1000 itself is the tenant's batch user.
+	auditStaff = 20
+	// auditStream forks the audit source, so turning the audit on leaves
+	// the rest of the seeded data as it was
+	auditStream = 0xa0d17
+)
+
+// staffAgents are the browsers back-office staff work in
+var staffAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.51",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+}
+
+// batchAgent is the client of the batch user
+const batchAgent = "core-banking-batch/3.2 (ledger)"
+
+// actor is a user named by audit rows, with the address and client it
+// works from
+type actor struct {
+	userID int64
+	ip     string
+	agent  string
+}
+
+// auditLog writes an audit_logs row for each row the seeder creates or
+// changes, in the transaction of the change. The before and after images
+// are read back from the table, so they match what was written. A nil
+// *auditLog audits nothing.
+type auditLog struct {
+	db     *sql.DB
+	src    *rng.Source
+	staff  map[int64][]actor // by tenant
+	batch  map[int64]actor   // by tenant
+	months map[time.Time]bool
+}
+
+// newAuditLog gives every tenant its staff and batch user, working from
+// fixed addresses in the tenant's country
+func newAuditLog(ctx context.Context, db *sql.DB, src *rng.Source, locales map[int64]*person.Locale) (*auditLog, error) {
+	a := &auditLog{
+		db:     db,
+		src:    src.Fork(auditStream),
+		staff:  make(map[int64][]actor, len(locales)),
+		batch:  make(map[int64]actor, len(locales)),
+		months: map[time.Time]bool{},
+	}
+	// In tenant order, so the same seed gives the same users
+	for _, tenantID := range slices.Sorted(maps.Keys(locales)) {
+		country, err := geo.Lookup(locales[tenantID].Country)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %d: %w", tenantID, err)
+		}
+		base := tenantID * 1000
+		a.batch[tenantID] = actor{userID: base, ip: country.IPv4(a.src).String(), agent: batchAgent}
+		for n := range auditStaff {
+			a.staff[tenantID] = append(a.staff[tenantID], actor{userID: base + int64(n) + 1,
+				ip: country.IPv4(a.src).String(), agent: staffAgents[a.src.Intn(len(staffAgents))]})
+		}
+	}
+	return a, a.cover(ctx, src.Now, src.Now)
+}
+
+// allTenantsAudit is newAuditLog for every tenant in the database
+func allTenantsAudit(ctx context.Context, db *sql.DB, src *rng.Source) (*auditLog, error) {
+	rows, err := db.QueryContext(ctx, "SELECT tenant_id FROM tenants")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tenantIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		tenantIDs = append(tenantIDs, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	locales, err := tenantLocales(ctx, db, tenantIDs)
+	if err != nil {
+		return nil, err
+	}
+	return newAuditLog(ctx, db, src, locales)
+}
+
+// staffUser picks a member of a tenant's staff
+func (a *auditLog) staffUser(tenantID int64) actor {
+	if a == nil {
+		return actor{}
+	}
+	staff := a.staff[tenantID]
+	return staff[a.src.Intn(len(staff))]
+}
+
+// batchUser is a tenant's batch user
+func (a *auditLog) batchUser(tenantID int64) actor {
+	if a == nil {
+		return actor{}
+	}
+	return a.batch[tenantID]
+}
+
+// cover makes sure audit_logs has partitions for from through to. Call it
+// before the transaction that audits rows of those dates.
+func (a *auditLog) cover(ctx context.Context, from, to time.Time) error {
+	if a == nil {
+		return nil
+	}
+	from, to = from.UTC(), to.UTC()
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	missing := false
+	for m := first; !m.After(to); m = m.AddDate(0, 1, 0) {
+		missing = missing || !a.months[m]
+	}
+	if !missing {
+		return nil
+	}
+	if err := ensurePartitions(ctx, a.db, "audit_logs", from, to); err != nil {
+		return err
+	}
+	for m := first; !m.After(to); m = m.AddDate(0, 1, 0) {
+		a.months[m] = true
+	}
+	return nil
+}
+
+// auditStatement is an INSERT into audit_logs that reads the audited rows
+// in the same transaction; the zero one does nothing
+type auditStatement struct {
+	query string
+	args  []any
+}
+
+func (s auditStatement) copyTx(ctx context.Context, tx pgx.Tx) error {
+	if s.query == "" {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, s.query, s.args...); err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+	return nil
+}
+
+func (s auditStatement) sqlTx(ctx context.Context, tx *sql.Tx) error {
+	if s.query == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+	return nil
+}
+
+// staffArgs picks a member of staff of its tenant for each row and returns
+// the rows' ids and their actors as array parameters
+func (a *auditLog) staffArgs(ids, tenantIDs []int64) []any {
+	users := make([]int64, len(ids))
+	ips := make([]string, len(ids))
+	agents := make([]string, len(ids))
+	for i, tenantID := range tenantIDs {
+		who := a.staffUser(tenantID)
+		users[i], ips[i], agents[i] = who.userID, who.ip, who.agent
+	}
+	return []any{ids, users, ips, agents}
+}
+
+// batchArgs returns the batch users as array parameters
+func (a *auditLog) batchArgs() []any {
+	var tenants, users []int64
+	var ips, agents []string
+	for tenantID, who := range a.batch {
+		tenants, users = append(tenants, tenantID), append(users, who.userID)
+		ips, agents = append(ips, who.ip), append(agents, who.agent)
+	}
+	return []any{tenants, users, ips, agents}
+}
+
+const auditInsert = `INSERT INTO audit_logs (tenant_id, user_id, entity_type, entity_id, action,
+	old_values, new_values, ip_address, user_agent, created_at) `
+
+// created audits the creation of the rows of table with ids by staff of
+// their tenants, tenantIDs[i] being the tenant of ids[i]. The entity type
+// is the table name in the singular.
+func (a *auditLog) created(table, idColumn string, ids, tenantIDs []int64) auditStatement {
+	if a == nil || len(ids) == 0 {
+		return auditStatement{}
+	}
+	return auditStatement{
+		query: auditInsert + fmt.Sprintf(`
+			SELECT t.tenant_id, s.user_id, '%[3]s', t.%[2]s, 'create',
+				NULL, to_jsonb(t), s.ip::inet, s.agent, t.created_at
+			FROM unnest($1::bigint[], $2::bigint[], $3::text[], $4::text[]) AS s(id, user_id, ip, agent)
+			JOIN %[1]s t ON t.%[2]s = s.id`, table, idColumn, entityType(table)),
+		args: a.staffArgs(ids, tenantIDs),
+	}
+}
+
+// loansCreated is created for loans, plus the status change of the loans
+// that are no longer active, made by the batch user as of updated_at.
+// Loans open as active, so the creation image has the status and
+// updated_at they were opened with.
+func (a *auditLog) loansCreated(ids, tenantIDs []int64) auditStatement {
+	if a == nil || len(ids) == 0 {
+		return auditStatement{}
+	}
+	opened := `jsonb_build_object('status', 'active', 'updated_at', t.created_at)`
+	return auditStatement{
+		query: auditInsert + `
+			SELECT t.tenant_id, s.user_id, 'loan', t.loan_id, 'create',
+				NULL, to_jsonb(t) || ` + opened + `, s.ip::inet, s.agent, t.created_at
+			FROM unnest($1::bigint[], $2::bigint[], $3::text[], $4::text[]) AS s(id, user_id, ip, agent)
+			JOIN loans t ON t.loan_id = s.id
+			UNION ALL
+			SELECT t.tenant_id, b.user_id, 'loan', t.loan_id, 'status_change',
+				` + opened + `, jsonb_build_object('status', t.status, 'updated_at', t.updated_at),
+				b.ip::inet, b.agent, t.updated_at
+			FROM loans t
+			JOIN unnest($5::bigint[], $6::bigint[], $7::text[], $8::text[]) AS b(tenant_id, user_id, ip, agent)
+				ON b.tenant_id = t.tenant_id
+			WHERE t.loan_id = ANY($1) AND t.status <> 'active'`,
+		args: append(a.staffArgs(ids, tenantIDs), a.batchArgs()...),
+	}
+}
+
+// balancesUpdated wraps updateBalances to audit each change as made by the
+// batch user of the account's tenant
+func (a *auditLog) balancesUpdated() auditStatement {
+	if a == nil {
+		return auditStatement{}
+	}
+	return auditStatement{
+		query: "WITH changed AS (" + updateBalances + ")\n" + auditInsert + `
+			SELECT c.tenant_id, b.user_id, 'account_balance', c.balance_id, 'update',
+				c.old_values, c.new_values, b.ip::inet, b.agent, c.updated_at
+			FROM changed c
+			JOIN unnest($5::bigint[], $6::bigint[], $7::text[], $8::text[]) AS b(tenant_id, user_id, ip, agent)
+				ON b.tenant_id = c.tenant_id`,
+		args: a.batchArgs(),
+	}
+}
+
+// auditKeys are the id columns audit rows name entities by
+var auditKeys = map[string]string{
+	"customers":        "customer_id",
+	"accounts":         "account_id",
+	"account_balances": "balance_id",
+	"transactions":     "transaction_id",
+}
+
+// rowKey picks a row by the values of its key columns. Partitioned tables
+// need their partition column in it, or every partition is scanned.
+type rowKey struct {
+	columns []string
+	values  []any
+}
+
+// byID picks the row whose id column equals id
+func byID(column string, id int64) rowKey {
+	return rowKey{columns: []string{column}, values: []any{id}}
+}
+
+// where matches the key against alias t, numbering its placeholders from
+// first
+func (k rowKey) where(first int) string {
+	terms := make([]string, len(k.columns))
+	for i, c := range k.columns {
+		terms[i] = fmt.Sprintf("t.%s = $%d", c, first+i)
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// updated runs update, which changes the row of table at key, and audits it
+// as action by who, with the row before and after. The row is locked until
+// the transaction ends, so the images are exact.
+func (a *auditLog) updated(ctx context.Context, tx *sql.Tx, who actor, table string, key rowKey, action string, update func() error) error {
+	if a == nil {
+		return update()
+	}
+	var before []byte
+	err := tx.QueryRowContext(ctx,
+		fmt.Sprintf("SELECT to_jsonb(t) FROM %s t WHERE %s FOR UPDATE", table, key.where(1)), key.values...).Scan(&before)
+	if errors.Is(err, sql.ErrNoRows) {
+		return update() // nothing to change, nothing to audit
+	}
+	if err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, auditInsert+fmt.Sprintf(`
+		SELECT t.tenant_id, $1::bigint, '%s', t.%s, $2::text, $3::jsonb, to_jsonb(t), $4::inet, $5::text, t.updated_at
+		FROM %s t WHERE %s`, entityType(table), auditKeys[table], table, key.where(6)),
+		append([]any{who.userID, action, string(before), who.ip, who.agent}, key.values...)...)
+	if err != nil {
+		return fmt.Errorf("audit failed: %w", err)
+	}
+	return nil
+}
+
+// entityType names the entity of a table's rows
+func entityType(table string) string {
+	return table[:len(table)-1]
+}
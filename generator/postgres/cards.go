@@ -0,0 +1,304 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"datagenerator/generator/card"
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/merchant"
+	"datagenerator/generator/person"
+	"datagenerator/generator/rng"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// cardValidity is how long a card is valid from its issue date, in years
+const cardValidity = 4
+
+// seedCards issues a card to CardRatio of the accounts without one, after
+// the account opened and before the transaction window. The database gets
+// the hash and last four digits of each number; the number itself only
+// goes to PANFile, when one is set.
+func seedCards(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, accounts []AccountInfo, config SeedConfig) error {
+	existing, err := idsIn(ctx, db, "cards", "account_id")
+	if err != nil {
+		return err
+	}
+	var pans *panFile
+	if config.PANFile != "" {
+		if pans, err = openPANFile(config.PANFile); err != nil {
+			return err
+		}
+		defer pans.Close()
+	}
+	hasher := card.NewHasher(config.CardHashKey)
+
+	batch := newRowBatcher(db, config.Copy, "cards", cardCopyColumns, config.BatchSize)
+	for _, account := range accounts {
+		if existing[account.AccountID] || src.Float64() >= config.CardRatio {
+			continue
+		}
+		row, pan, err := cardFields(src, cats, hasher, account, config.Time.Start)
+		if err != nil {
+			return err
+		}
+		if pans != nil {
+			if err := pans.write(row, pan); err != nil {
+				return err
+			}
+		}
+		if err := batch.add(ctx, row); err != nil {
+			return err
+		}
+	}
+	if err := batch.flush(ctx); err != nil {
+		return err
+	}
+	if pans != nil {
+		if err := pans.Close(); err != nil {
+			return err
+		}
+	}
+	log.Printf("  ✓ Created %d cards\n", batch.written)
+	return nil
+}
+
+// cardFields builds a cards row for a card issued some day after the
+// account opened and before start, and returns its number
+func cardFields(src *rng.Source, cats *categories, hasher card.Hasher, account AccountInfo, start time.Time) ([]any, string, error) {
+	issued := firstDay(account.Opened)
+	if days := int(start.Sub(issued).Hours() / 24); days > 0 {
+		issued = issued.AddDate(0, 0, src.Intn(days))
+	}
+	expiry := issued.AddDate(cardValidity, 0, 0)
+
+	brand := cats.cardBrands.Pick(src)
+	pan, err := card.PAN(src, brand)
+	if err != nil {
+		return nil, "", err
+	}
+	return []any{account.TenantID, account.AccountID, account.CustomerID,
+		hasher.Hash(pan), card.LastFour(pan), cats.cardTypes.Pick(src), brand,
+		int(expiry.Month()), expiry.Year(), "active", issued, issued, issued}, pan, nil
+}
+
+// panFile is the CSV side file of generated card numbers, keyed by the
+// hash stored in cards
+type panFile struct {
+	f   *os.File
+	out *csv.Writer
+}
+
+// openPANFile opens path for appending, readable by its owner only, and
+// writes the header if the file is new
+func openPANFile(path string) (*panFile, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("PAN file: %w", err)
+	}
+	p := &panFile{f: f, out: csv.NewWriter(f)}
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		p.out.Write([]string{"card_number_hash", "pan", "card_brand", "expiry_month", "expiry_year"})
+	}
+	return p, nil
+}
+
+// write records pan next to the hash, brand and expiry of its cards row
+func (p *panFile) write(row []any, pan string) error {
+	return p.out.Write([]string{row[3].(string), pan, row[6].(string),
+		fmt.Sprintf("%02d", row[7]), fmt.Sprint(row[8])})
+}
+
+// Close flushes the file; closing it again does nothing
+func (p *panFile) Close() error {
+	if p.f == nil {
+		return nil
+	}
+	p.out.Flush()
+	err := p.out.Error()
+	if cerr := p.f.Close(); err == nil {
+		err = cerr
+	}
+	p.f = nil
+	if err != nil {
+		return fmt.Errorf("PAN file: %w", err)
+	}
+	return nil
+}
+
+// cardKey is an active card, its tenant, holder and issue date
+type cardKey struct {
+	CardID     int64
+	TenantID   int64
+	CustomerID int64
+	Issued     time.Time
+}
+
+// seedCardTransactions creates CardTransactions authorizations on active
+// cards of the tenants in locales. Cards are picked with the same skew as
+// accounts. Merchants are in the tenant's country except for
+// ForeignCardRatio of the payments, which are abroad and charged in the
+// merchant's currency. FraudRatio of the authorizations come from card
+// testing episodes, each alerted in fraud_alerts. See planCardTesting.
+func seedCardTransactions(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, locales map[int64]*person.Locale, config SeedConfig) error {
+	if config.CardTransactions == 0 {
+		return nil
+	}
+	tenantIDs := make([]int64, 0, len(locales))
+	for id := range locales {
+		tenantIDs = append(tenantIDs, id)
+	}
+	cards, err := activeCards(ctx, db, tenantIDs)
+	if err != nil {
+		return err
+	}
+	if len(cards) == 0 {
+		return nil
+	}
+
+	pick := distribution.Params{}
+	if config.AccountSkew != 0 {
+		pick = distribution.Params{Dist: "zipf", S: config.AccountSkew}
+	}
+	card, err := pick.Index(len(cards))
+	if err != nil {
+		return fmt.Errorf("account skew: %w", err)
+	}
+	countries := merchant.Codes()
+	fraud, err := planCardTesting(src, cards, locales, config.Time, config.CardTransactions, config.FraudRatio)
+	if err != nil {
+		return fmt.Errorf("card testing: %w", err)
+	}
+
+	batch := newRowBatcher(db, config.Copy, "card_transactions", cardTransactionCopyColumns, config.BatchSize)
+	declined := 0
+	for range config.CardTransactions - len(fraud.txns) {
+		c := cards[min(int(card.Sample(src)), len(cards)-1)]
+		home := locales[c.TenantID].Country
+
+		where, scope := home, "domestic"
+		if src.Float64() < config.ForeignCardRatio {
+			for where == home {
+				where = countries[src.Intn(len(countries))]
+			}
+			scope = "foreign"
+		}
+		market, err := merchant.In(where)
+		if err != nil {
+			return err
+		}
+		p := market.Purchase(src)
+
+		status := cats.cardStatus.Pick(src, scope)
+		var authCode any
+		if status == "approved" {
+			authCode = fmt.Sprintf("%06d", src.Intn(1000000))
+		} else {
+			declined++
+		}
+		date := config.Time.Draw(src)
+		if date.Before(c.Issued) {
+			date = c.Issued
+		}
+
+		err = batch.add(ctx, []any{c.CardID, c.TenantID, p.Merchant.Name, p.Merchant.Category.MCC,
+			p.Merchant.Country, p.Amount, p.Currency, status, authCode, date, date})
+		if err != nil {
+			return err
+		}
+	}
+	if err := batch.flush(ctx); err != nil {
+		return err
+	}
+	for txns := fraud.txns; len(txns) > 0; {
+		n := min(config.BatchSize, len(txns))
+		if err := writeCardFraud(ctx, db, config.Copy, txns[:n]); err != nil {
+			return err
+		}
+		txns = txns[n:]
+	}
+	for _, f := range fraud.txns {
+		if f.status == "declined" {
+			declined++
+		}
+	}
+	log.Printf("  ✓ Created %d card transactions, %d declined\n", batch.written+len(fraud.txns), declined)
+	if fraud.episodes > 0 {
+		log.Printf("  ✓ Injected %d card testing authorizations in %d episodes, each with a fraud alert\n",
+			len(fraud.txns), fraud.episodes)
+	}
+	return nil
+}
+
+// writeCardFraud writes card testing authorizations and their alerts in
+// one transaction
+func writeCardFraud(ctx context.Context, db *sql.DB, useCopy bool, txns []*fraudTxn) error {
+	rows := func(ids []int64) (auths, alerts [][]any) {
+		for i, f := range txns {
+			auths = append(auths, append([]any{ids[i]}, f.cardRow()...))
+			alerts = append(alerts, f.cardAlert(ids[i]))
+		}
+		return auths, alerts
+	}
+	if useCopy {
+		return withCopyTx(ctx, db, func(tx pgx.Tx) error {
+			ids, err := reserveIDs(ctx, tx, "card_transactions", "card_txn_id", len(txns))
+			if err != nil {
+				return err
+			}
+			auths, alerts := rows(ids)
+			if err := copyRows(ctx, tx, "card_transactions", cardTransactionIDCopyColumns, auths); err != nil {
+				return err
+			}
+			return copyRows(ctx, tx, "fraud_alerts", cardFraudAlertCopyColumns, alerts)
+		})
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	ids, err := nextIDs(ctx, tx, "card_transactions", "card_txn_id", len(txns))
+	if err != nil {
+		return err
+	}
+	auths, alerts := rows(ids)
+	if err := insertRows(ctx, tx, "card_transactions", cardTransactionIDCopyColumns, auths); err != nil {
+		return err
+	}
+	if err := insertRows(ctx, tx, "fraud_alerts", cardFraudAlertCopyColumns, alerts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// activeCards loads the active cards of tenantIDs, oldest first
+func activeCards(ctx context.Context, db *sql.DB, tenantIDs []int64) ([]cardKey, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT card_id, tenant_id, customer_id, COALESCE(issued_date, created_at::date)
+		FROM cards
+		WHERE status = 'active' AND tenant_id = ANY($1)
+		ORDER BY card_id
+	`, tenantIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []cardKey
+	for rows.Next() {
+		var c cardKey
+		if err := rows.Scan(&c.CardID, &c.TenantID, &c.CustomerID, &c.Issued); err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
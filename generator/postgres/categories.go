@@ -0,0 +1,90 @@
+package generator
+
+import (
+	_ "embed"
+	"fmt"
+
+	"datagenerator/generator/weighted"
+)
+
+//go:embed weights.yaml
+var builtinWeights []byte
+
+// categories are the weighted choices of weights.yaml
+type categories struct {
+	countries        *weighted.Choice[string]
+	accountTypes     *weighted.Choice[string]
+	currencies       *weighted.Choice[string]
+	transactionTypes *weighted.Choice[string]
+	// transactionStatus is weighted by transaction type
+	transactionStatus *weighted.Conditional[string, string]
+	settledStatus     *weighted.Choice[string]
+	cardTypes         *weighted.Choice[string]
+	cardBrands        *weighted.Choice[string]
+	kycDocumentTypes  *weighted.Choice[string]
+	documentTypes     *weighted.Choice[string]
+	loanTypes         *weighted.Choice[string]
+	fraudScenarios    *weighted.Choice[string]
+	// mimeTypes is weighted by document type
+	mimeTypes *weighted.Conditional[string, string]
+	// cardStatus is weighted by domestic or foreign merchant
+	cardStatus *weighted.Conditional[string, string]
+}
+
+// loadCategories reads the weights file at path, or the built-in weights
+// when path is empty
+func loadCategories(path string) (*categories, error) {
+	specs, err := weighted.ParseSpecs(builtinWeights)
+	if path != "" {
+		specs, err = weighted.LoadSpecs(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	spec := func(name string) (weighted.Spec, error) {
+		s, ok := specs[name]
+		if !ok {
+			return s, fmt.Errorf("missing")
+		}
+		return s, nil
+	}
+
+	c := &categories{}
+	for name, dst := range map[string]**weighted.Choice[string]{
+		"tenant_country":         &c.countries,
+		"account_type":           &c.accountTypes,
+		"currency":               &c.currencies,
+		"transaction_type":       &c.transactionTypes,
+		"settled_status":         &c.settledStatus,
+		"card_type":              &c.cardTypes,
+		"card_brand":             &c.cardBrands,
+		"kyc_document_type":      &c.kycDocumentTypes,
+		"customer_document_type": &c.documentTypes,
+		"loan_type":              &c.loanTypes,
+		"fraud_scenario":         &c.fraudScenarios,
+	} {
+		s, err := spec(name)
+		if err == nil {
+			*dst, err = s.Choice()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("weights: %s: %w", name, err)
+		}
+	}
+
+	for name, dst := range map[string]**weighted.Conditional[string, string]{
+		"transaction_status":      &c.transactionStatus,
+		"document_mime_type":      &c.mimeTypes,
+		"card_transaction_status": &c.cardStatus,
+	} {
+		s, err := spec(name)
+		if err == nil {
+			*dst, err = s.Conditional()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("weights: %s: %w", name, err)
+		}
+	}
+	return c, nil
+}
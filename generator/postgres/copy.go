@@ -0,0 +1,197 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Column lists for the COPY paths. Surrogate keys are reserved up front with
+// reserveIDs so related rows can be built without RETURNING.
+var (
+	customerCopyColumns = []string{"customer_id", "tenant_id", "customer_code", "first_name", "last_name",
+		"email", "phone", "date_of_birth", "nationality", "status", "created_at", "updated_at"}
+	addressCopyColumns = []string{"customer_id", "tenant_id", "address_type", "address_line1", "address_line2",
+		"city", "state", "postal_code", "country", "created_at", "updated_at"}
+	beneficiaryCopyColumns = []string{"tenant_id", "customer_id", "beneficiary_name", "account_number",
+		"bank_code", "bank_name", "status", "created_at", "updated_at"}
+	kycCopyColumns = []string{"customer_id", "tenant_id", "document_type", "document_number",
+		"verification_status", "verified_at", "expiry_date", "created_at", "updated_at"}
+	documentCopyColumns = []string{"customer_id", "tenant_id", "document_type", "document_name",
+		"document_url", "file_size", "mime_type", "created_at"}
+	cardCopyColumns = []string{"tenant_id", "account_id", "customer_id", "card_number_hash", "card_last_four",
+		"card_type", "card_brand", "expiry_month", "expiry_year", "status", "issued_date", "created_at", "updated_at"}
+	cardTransactionCopyColumns = []string{"card_id", "tenant_id", "merchant_name", "merchant_category",
+		"merchant_country", "amount", "currency_code", "status", "authorization_code", "transaction_date",
+		"created_at"}
+	loanCopyColumns = []string{"loan_id", "tenant_id", "customer_id", "account_id", "loan_number", "loan_type",
+		"principal_amount", "interest_rate", "tenure_months", "status", "disbursement_date", "maturity_date",
+		"created_at", "updated_at"}
+	loanScheduleCopyColumns = []string{"loan_id", "tenant_id", "installment_number", "due_date", "principal_due",
+		"interest_due", "total_due", "status", "created_at"}
+	loanRepaymentCopyColumns = []string{"loan_id", "tenant_id", "repayment_date", "principal_amount",
+		"interest_amount", "penalty_amount", "total_amount", "status", "created_at"}
+	accountCopyColumns = []string{"account_id", "tenant_id", "account_number", "account_type", "currency_code",
+		"status", "opened_date", "created_at", "updated_at"}
+	accountHolderCopyColumns  = []string{"account_id", "customer_id", "tenant_id", "holder_type", "created_at"}
+	accountBalanceCopyColumns = []string{"account_id", "tenant_id", "available_balance", "current_balance", "updated_at"}
+	transactionLegCopyColumns = []string{"transaction_id", "tenant_id", "account_id", "leg_type", "amount",
+		"balance_after", "created_at"}
+	transactionCopyColumns = []string{"transaction_id", "tenant_id", "transaction_ref", "from_account_id", "to_account_id",
+		"transaction_type", "amount", "currency_code", "status", "description", "transaction_date",
+		"created_at", "updated_at"}
+	transactionMetadataCopyColumns = []string{"transaction_id", "tenant_id", "metadata_key", "metadata_value", "created_at"}
+	fraudAlertCopyColumns          = []string{"tenant_id", "transaction_id", "customer_id", "alert_type", "risk_score",
+		"status", "description", "detected_at", "resolved_at", "created_at"}
+	cardTransactionIDCopyColumns = append([]string{"card_txn_id"}, cardTransactionCopyColumns...)
+	cardFraudAlertCopyColumns    = []string{"tenant_id", "card_txn_id", "customer_id", "alert_type", "risk_score",
+		"status", "description", "detected_at", "resolved_at", "created_at"}
+)
+
+// withCopyTx runs fn in a transaction on the pgx connection underneath one
+// of db's pooled connections
+func withCopyTx(ctx context.Context, db *sql.DB, fn func(pgx.Tx) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pgxConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("COPY needs the pgx driver, got %T", driverConn)
+		}
+		return pgx.BeginFunc(ctx, pgxConn.Conn(), fn)
+	})
+}
+
+// reserveIDs draws n values from the serial sequence behind table.column
+func reserveIDs(ctx context.Context, tx pgx.Tx, table, column string, n int) ([]int64, error) {
+	rows, err := tx.Query(ctx,
+		"SELECT nextval(pg_get_serial_sequence($1, $2)) FROM generate_series(1, $3)", table, column, n)
+	if err != nil {
+		return nil, fmt.Errorf("reserving %s ids: %w", table, err)
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int64])
+}
+
+// nextIDs is reserveIDs for the INSERT paths
+func nextIDs(ctx context.Context, tx *sql.Tx, table, column string, n int) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT nextval(pg_get_serial_sequence($1, $2)) FROM generate_series(1, $3)", table, column, n)
+	if err != nil {
+		return nil, fmt.Errorf("reserving %s ids: %w", table, err)
+	}
+	defer rows.Close()
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// copyRows streams rows into table with COPY FROM STDIN, if there are any
+func copyRows(ctx context.Context, tx pgx.Tx, table string, columns []string, rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{table}, columns, pgx.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("copy into %s failed: %w", table, err)
+	}
+	return nil
+}
+
+// insertRows writes rows into table as multi-VALUES INSERTs, as many rows
+// per statement as the bind parameter limit allows
+func insertRows(ctx context.Context, tx *sql.Tx, table string, columns []string, rows [][]any) error {
+	perStatement := maxBindParams / len(columns)
+	for len(rows) > 0 {
+		chunk := rows[:min(perStatement, len(rows))]
+		rows = rows[len(chunk):]
+
+		valueStrings := make([]string, 0, len(chunk))
+		valueArgs := make([]any, 0, len(chunk)*len(columns))
+		placeholders := make([]string, len(columns))
+		for _, row := range chunk {
+			for i := range placeholders {
+				placeholders[i] = fmt.Sprintf("$%d", len(valueArgs)+i+1)
+			}
+			valueStrings = append(valueStrings, "("+strings.Join(placeholders, ", ")+")")
+			valueArgs = append(valueArgs, row...)
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+			table, strings.Join(columns, ", "), strings.Join(valueStrings, ","))
+		if _, err := tx.ExecContext(ctx, query, valueArgs...); err != nil {
+			return fmt.Errorf("insert into %s failed: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// writeRows loads rows into table in one transaction, with COPY or with
+// INSERTs
+func writeRows(ctx context.Context, db *sql.DB, useCopy bool, table string, columns []string, rows [][]any) error {
+	if useCopy {
+		return withCopyTx(ctx, db, func(tx pgx.Tx) error {
+			return copyRows(ctx, tx, table, columns, rows)
+		})
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := insertRows(ctx, tx, table, columns, rows); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// rowBatcher collects rows for one table and writes them with writeRows
+// every size rows
+type rowBatcher struct {
+	db      *sql.DB
+	useCopy bool
+	table   string
+	columns []string
+	size    int
+
+	rows    [][]any
+	written int // rows written so far
+}
+
+func newRowBatcher(db *sql.DB, useCopy bool, table string, columns []string, size int) *rowBatcher {
+	return &rowBatcher{db: db, useCopy: useCopy, table: table, columns: columns, size: size}
+}
+
+// add queues row, writing the batch once it is full
+func (b *rowBatcher) add(ctx context.Context, row []any) error {
+	b.rows = append(b.rows, row)
+	if len(b.rows) < b.size {
+		return nil
+	}
+	return b.flush(ctx)
+}
+
+// flush writes the queued rows
+func (b *rowBatcher) flush(ctx context.Context) error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+	if err := writeRows(ctx, b.db, b.useCopy, b.table, b.columns, b.rows); err != nil {
+		return err
+	}
+	b.written += len(b.rows)
+	b.rows = b.rows[:0]
+	return nil
+}
@@ -5,26 +5,28 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"math/rand"
+	"math"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	_ "github.com/lib/pq" // postgres driver
+	"datagenerator/config"
+	"datagenerator/generator/bank"
+	"datagenerator/generator/dataset"
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/person"
+	"datagenerator/generator/pool"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/timeline"
+
+	"github.com/jackc/pgx/v5"
+	_ "github.com/jackc/pgx/v5/stdlib" // postgres driver, also used for COPY
 )
 
-func CreateAirportDemoPostgresSchema() {
-	username := "postgres"
-	password := "postgres"
-	host := "localhost"
-	port := 5432
-	database := "banking_db"
-
-	connStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		host, port, username, password, database,
-	)
-
-	db, err := sql.Open("postgres", connStr)
+// CreateAirportDemoPostgresSchema creates the tables of ds (normally the
+// built-in banking dataset) plus the ETL metadata schemas
+func CreateAirportDemoPostgresSchema(conn config.Connection, ds *dataset.Dataset) {
+	db, err := sql.Open("pgx", conn.PostgresDSN())
 	if err != nil {
 		log.Printf("error: %v", err.Error())
 		return
@@ -47,7 +49,7 @@ func CreateAirportDemoPostgresSchema() {
 
 	log.Println("Starting schema creation...")
 
-	if err := createSchema(ctx, db); err != nil {
+	if err := createSchema(ctx, db, ds); err != nil {
 		log.Fatalf("Failed to create schema: %v", err)
 	}
 
@@ -57,450 +59,57 @@ func CreateAirportDemoPostgresSchema() {
 
 	log.Println("Schema creation completed successfully!")
 }
-func createSchema(ctx context.Context, db *sql.DB) error {
-	schemas := []string{
-		createTenantTables(),
-		createCustomerTables(),
-		createAccountTables(),
-		createTransactionTables(),
-		createPaymentInstrumentTables(),
-		createLoanTables(),
-		createAuditTables(),
-		createIndexes(),
-	}
 
-	for _, schema := range schemas {
-		if _, err := db.ExecContext(ctx, schema); err != nil {
-			return fmt.Errorf("error executing schema: %w", err)
+// createSchema renders the dataset's tables as Postgres DDL and runs them in
+// spec order, so referenced tables exist before the tables that point at them
+func createSchema(ctx context.Context, db *sql.DB, ds *dataset.Dataset) error {
+	for _, table := range ds.Tables {
+		for _, stmt := range dataset.Postgres.CreateTable(table) {
+			if _, err := db.ExecContext(ctx, stmt.SQL); err != nil {
+				return fmt.Errorf("error creating %s %s: %w", strings.ToLower(stmt.Object), stmt.Name, err)
+			}
 		}
 	}
 
 	return nil
 }
 
-func createTenantTables() string {
-	return `
--- Tenant Management Layer
-CREATE TABLE IF NOT EXISTS tenants (
-    tenant_id BIGSERIAL PRIMARY KEY,
-    tenant_code VARCHAR(50) UNIQUE NOT NULL,
-    tenant_name VARCHAR(255) NOT NULL,
-    status VARCHAR(20) DEFAULT 'active',
-    country_code VARCHAR(3),
-    timezone VARCHAR(50) DEFAULT 'UTC',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS tenant_configurations (
-    config_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id) ON DELETE CASCADE,
-    config_key VARCHAR(100) NOT NULL,
-    config_value TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(tenant_id, config_key)
-);
-`
-}
-
-func createCustomerTables() string {
-	return `
--- Customer Domain
-CREATE TABLE IF NOT EXISTS customers (
-    customer_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    customer_code VARCHAR(50) NOT NULL,
-    first_name VARCHAR(100) NOT NULL,
-    last_name VARCHAR(100) NOT NULL,
-    email VARCHAR(255),
-    phone VARCHAR(20),
-    date_of_birth DATE,
-    nationality VARCHAR(3),
-    status VARCHAR(20) DEFAULT 'active',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(tenant_id, customer_code)
-);
-
-CREATE TABLE IF NOT EXISTS customer_kyc (
-    kyc_id BIGSERIAL PRIMARY KEY,
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    document_type VARCHAR(50) NOT NULL,
-    document_number VARCHAR(100) NOT NULL,
-    verification_status VARCHAR(20) DEFAULT 'pending',
-    verified_at TIMESTAMP,
-    expiry_date DATE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS customer_addresses (
-    address_id BIGSERIAL PRIMARY KEY,
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    address_type VARCHAR(20) DEFAULT 'primary',
-    address_line1 VARCHAR(255),
-    address_line2 VARCHAR(255),
-    city VARCHAR(100),
-    state VARCHAR(100),
-    postal_code VARCHAR(20),
-    country VARCHAR(3),
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS customer_documents (
-    document_id BIGSERIAL PRIMARY KEY,
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    document_type VARCHAR(50),
-    document_name VARCHAR(255),
-    document_url TEXT,
-    file_size BIGINT,
-    mime_type VARCHAR(100),
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
-}
-
-func createAccountTables() string {
-	return `
--- Account Domain
-CREATE TABLE IF NOT EXISTS accounts (
-    account_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    account_number VARCHAR(50) NOT NULL,
-    account_type VARCHAR(30) NOT NULL,
-    currency_code VARCHAR(3) DEFAULT 'USD',
-    status VARCHAR(20) DEFAULT 'active',
-    opened_date DATE DEFAULT CURRENT_DATE,
-    closed_date DATE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(tenant_id, account_number)
-);
-
-CREATE TABLE IF NOT EXISTS account_holders (
-    holder_id BIGSERIAL PRIMARY KEY,
-    account_id BIGINT NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    holder_type VARCHAR(20) DEFAULT 'primary',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(account_id, customer_id)
-);
-
-CREATE TABLE IF NOT EXISTS account_balances (
-    balance_id BIGSERIAL PRIMARY KEY,
-    account_id BIGINT NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    available_balance DECIMAL(20, 4) DEFAULT 0.00,
-    current_balance DECIMAL(20, 4) DEFAULT 0.00,
-    hold_balance DECIMAL(20, 4) DEFAULT 0.00,
-    last_transaction_date TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(account_id)
-);
-`
-}
-
-func createTransactionTables() string {
-	return `
--- Transaction Domain (Partitioned for massive scale)
-CREATE TABLE IF NOT EXISTS transactions (
-    transaction_id BIGSERIAL,
-    tenant_id BIGINT NOT NULL,
-    transaction_ref VARCHAR(100) NOT NULL,
-    from_account_id BIGINT REFERENCES accounts(account_id),
-    to_account_id BIGINT REFERENCES accounts(account_id),
-    transaction_type VARCHAR(50) NOT NULL,
-    amount DECIMAL(20, 4) NOT NULL,
-    currency_code VARCHAR(3) DEFAULT 'USD',
-    status VARCHAR(20) DEFAULT 'pending',
-    description TEXT,
-    transaction_date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    value_date DATE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    PRIMARY KEY (transaction_id, tenant_id, transaction_date)
-) PARTITION BY RANGE (transaction_date);
-
--- Create initial partitions for transactions (example: quarterly)
-CREATE TABLE IF NOT EXISTS transactions_2024_q4 
-    PARTITION OF transactions 
-    FOR VALUES FROM ('2024-10-01') TO ('2025-01-01');
-
-CREATE TABLE IF NOT EXISTS transactions_2025_q1 
-    PARTITION OF transactions 
-    FOR VALUES FROM ('2025-01-01') TO ('2025-04-01');
-
-CREATE TABLE IF NOT EXISTS transactions_2025_q2 
-    PARTITION OF transactions 
-    FOR VALUES FROM ('2025-04-01') TO ('2025-07-01');
-
-CREATE TABLE IF NOT EXISTS transactions_2025_q3 
-    PARTITION OF transactions 
-    FOR VALUES FROM ('2025-07-01') TO ('2025-10-01');
-
-CREATE TABLE IF NOT EXISTS transactions_2025_q4 
-    PARTITION OF transactions 
-    FOR VALUES FROM ('2025-10-01') TO ('2026-01-01');
-
--- Double-entry bookkeeping
-CREATE TABLE IF NOT EXISTS transaction_legs (
-    leg_id BIGSERIAL PRIMARY KEY,
-    transaction_id BIGINT NOT NULL,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    account_id BIGINT NOT NULL REFERENCES accounts(account_id),
-    leg_type VARCHAR(10) NOT NULL CHECK (leg_type IN ('debit', 'credit')),
-    amount DECIMAL(20, 4) NOT NULL,
-    balance_after DECIMAL(20, 4),
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS pending_transactions (
-    pending_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    transaction_ref VARCHAR(100) NOT NULL,
-    from_account_id BIGINT REFERENCES accounts(account_id),
-    to_account_id BIGINT REFERENCES accounts(account_id),
-    amount DECIMAL(20, 4) NOT NULL,
-    currency_code VARCHAR(3) DEFAULT 'USD',
-    status VARCHAR(20) DEFAULT 'processing',
-    initiated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    expires_at TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS transaction_metadata (
-    metadata_id BIGSERIAL PRIMARY KEY,
-    transaction_id BIGINT NOT NULL,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    metadata_key VARCHAR(100) NOT NULL,
-    metadata_value TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
-}
-
-func createPaymentInstrumentTables() string {
-	return `
--- Payment Instruments
-CREATE TABLE IF NOT EXISTS cards (
-    card_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    account_id BIGINT NOT NULL REFERENCES accounts(account_id) ON DELETE CASCADE,
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    card_number_hash VARCHAR(255) NOT NULL,
-    card_last_four VARCHAR(4),
-    card_type VARCHAR(20),
-    card_brand VARCHAR(30),
-    expiry_month INT,
-    expiry_year INT,
-    status VARCHAR(20) DEFAULT 'active',
-    issued_date DATE DEFAULT CURRENT_DATE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS card_transactions (
-    card_txn_id BIGSERIAL PRIMARY KEY,
-    card_id BIGINT NOT NULL REFERENCES cards(card_id),
-    transaction_id BIGINT,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    merchant_name VARCHAR(255),
-    merchant_category VARCHAR(50),
-    amount DECIMAL(20, 4) NOT NULL,
-    currency_code VARCHAR(3) DEFAULT 'USD',
-    status VARCHAR(20) DEFAULT 'approved',
-    authorization_code VARCHAR(50),
-    transaction_date TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS beneficiaries (
-    beneficiary_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    beneficiary_name VARCHAR(255) NOT NULL,
-    account_number VARCHAR(50),
-    bank_code VARCHAR(50),
-    bank_name VARCHAR(255),
-    status VARCHAR(20) DEFAULT 'active',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
-}
-
-func createLoanTables() string {
-	return `
--- Loan/Credit Domain
-CREATE TABLE IF NOT EXISTS loans (
-    loan_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    customer_id BIGINT NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
-    account_id BIGINT REFERENCES accounts(account_id),
-    loan_number VARCHAR(50) NOT NULL,
-    loan_type VARCHAR(50) NOT NULL,
-    principal_amount DECIMAL(20, 4) NOT NULL,
-    interest_rate DECIMAL(5, 4),
-    tenure_months INT,
-    status VARCHAR(20) DEFAULT 'active',
-    disbursement_date DATE,
-    maturity_date DATE,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(tenant_id, loan_number)
-);
-
-CREATE TABLE IF NOT EXISTS loan_repayments (
-    repayment_id BIGSERIAL PRIMARY KEY,
-    loan_id BIGINT NOT NULL REFERENCES loans(loan_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    repayment_date DATE NOT NULL,
-    principal_amount DECIMAL(20, 4) DEFAULT 0.00,
-    interest_amount DECIMAL(20, 4) DEFAULT 0.00,
-    penalty_amount DECIMAL(20, 4) DEFAULT 0.00,
-    total_amount DECIMAL(20, 4) NOT NULL,
-    status VARCHAR(20) DEFAULT 'paid',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS loan_schedules (
-    schedule_id BIGSERIAL PRIMARY KEY,
-    loan_id BIGINT NOT NULL REFERENCES loans(loan_id) ON DELETE CASCADE,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    installment_number INT NOT NULL,
-    due_date DATE NOT NULL,
-    principal_due DECIMAL(20, 4),
-    interest_due DECIMAL(20, 4),
-    total_due DECIMAL(20, 4),
-    status VARCHAR(20) DEFAULT 'pending',
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
-}
-
-func createAuditTables() string {
-	return `
--- Audit & Compliance
-CREATE TABLE IF NOT EXISTS audit_logs (
-    audit_id BIGSERIAL,
-    tenant_id BIGINT NOT NULL,
-    user_id BIGINT,
-    entity_type VARCHAR(50),
-    entity_id BIGINT,
-    action VARCHAR(50) NOT NULL,
-    old_values JSONB,
-    new_values JSONB,
-    ip_address INET,
-    user_agent TEXT,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    PRIMARY KEY (audit_id, tenant_id, created_at)
-) PARTITION BY RANGE (created_at);
-
--- Create initial partitions for audit logs (monthly)
-CREATE TABLE IF NOT EXISTS audit_logs_2025_01 
-    PARTITION OF audit_logs 
-    FOR VALUES FROM ('2025-01-01') TO ('2025-02-01');
-
-CREATE TABLE IF NOT EXISTS audit_logs_2025_02 
-    PARTITION OF audit_logs 
-    FOR VALUES FROM ('2025-02-01') TO ('2025-03-01');
-
-CREATE TABLE IF NOT EXISTS audit_logs_2025_03 
-    PARTITION OF audit_logs 
-    FOR VALUES FROM ('2025-03-01') TO ('2025-04-01');
-
-CREATE TABLE IF NOT EXISTS fraud_alerts (
-    alert_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    transaction_id BIGINT,
-    customer_id BIGINT REFERENCES customers(customer_id),
-    alert_type VARCHAR(50) NOT NULL,
-    risk_score DECIMAL(5, 2),
-    status VARCHAR(20) DEFAULT 'open',
-    description TEXT,
-    detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    resolved_at TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
+// ensurePartitions adds the partitions a banking table needs for rows dated
+// from through to, beyond the ranges its spec creates up front
+func ensurePartitions(ctx context.Context, db *sql.DB, table string, from, to time.Time) error {
+	ds, err := dataset.Load("banking")
+	if err != nil {
+		return err
+	}
+	t, err := ds.Lookup(table)
+	if err != nil {
+		return err
+	}
 
-CREATE TABLE IF NOT EXISTS compliance_reports (
-    report_id BIGSERIAL PRIMARY KEY,
-    tenant_id BIGINT NOT NULL REFERENCES tenants(tenant_id),
-    report_type VARCHAR(50) NOT NULL,
-    report_period VARCHAR(20),
-    report_data JSONB,
-    generated_by BIGINT,
-    generated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-`
-}
+	rows, err := db.QueryContext(ctx, dataset.Postgres.ListPartitions(t))
+	if err != nil {
+		return fmt.Errorf("error listing %s partitions: %w", table, err)
+	}
+	defer rows.Close()
+	var existing []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing = append(existing, name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
-func createIndexes() string {
-	return `
--- Performance Indexes for ETL and Queries
--- Tenant indexes
-CREATE INDEX IF NOT EXISTS idx_tenants_code ON tenants(tenant_code);
-CREATE INDEX IF NOT EXISTS idx_tenants_status ON tenants(status);
-
--- Customer indexes
-CREATE INDEX IF NOT EXISTS idx_customers_tenant ON customers(tenant_id, created_at);
-CREATE INDEX IF NOT EXISTS idx_customers_email ON customers(email);
-CREATE INDEX IF NOT EXISTS idx_customers_phone ON customers(phone);
-CREATE INDEX IF NOT EXISTS idx_customers_status ON customers(tenant_id, status);
-
--- Account indexes
-CREATE INDEX IF NOT EXISTS idx_accounts_tenant ON accounts(tenant_id, created_at);
-CREATE INDEX IF NOT EXISTS idx_accounts_number ON accounts(account_number);
-CREATE INDEX IF NOT EXISTS idx_accounts_status ON accounts(tenant_id, status);
-
--- Account holders indexes
-CREATE INDEX IF NOT EXISTS idx_account_holders_customer ON account_holders(customer_id);
-CREATE INDEX IF NOT EXISTS idx_account_holders_tenant ON account_holders(tenant_id);
-
--- Transaction indexes (critical for ETL)
-CREATE INDEX IF NOT EXISTS idx_transactions_tenant_date ON transactions(tenant_id, transaction_date);
-CREATE INDEX IF NOT EXISTS idx_transactions_ref ON transactions(transaction_ref);
-CREATE INDEX IF NOT EXISTS idx_transactions_from_account ON transactions(from_account_id, transaction_date);
-CREATE INDEX IF NOT EXISTS idx_transactions_to_account ON transactions(to_account_id, transaction_date);
-CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(tenant_id, status, transaction_date);
-CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions(created_at);
-
--- Transaction legs indexes
-CREATE INDEX IF NOT EXISTS idx_transaction_legs_txn ON transaction_legs(transaction_id);
-CREATE INDEX IF NOT EXISTS idx_transaction_legs_account ON transaction_legs(account_id, created_at);
-CREATE INDEX IF NOT EXISTS idx_transaction_legs_tenant ON transaction_legs(tenant_id);
-
--- Card indexes
-CREATE INDEX IF NOT EXISTS idx_cards_tenant ON cards(tenant_id);
-CREATE INDEX IF NOT EXISTS idx_cards_account ON cards(account_id);
-CREATE INDEX IF NOT EXISTS idx_cards_customer ON cards(customer_id);
-CREATE INDEX IF NOT EXISTS idx_cards_status ON cards(status);
-
--- Card transaction indexes
-CREATE INDEX IF NOT EXISTS idx_card_txns_card ON card_transactions(card_id, transaction_date);
-CREATE INDEX IF NOT EXISTS idx_card_txns_tenant ON card_transactions(tenant_id, transaction_date);
-
--- Loan indexes
-CREATE INDEX IF NOT EXISTS idx_loans_tenant ON loans(tenant_id);
-CREATE INDEX IF NOT EXISTS idx_loans_customer ON loans(customer_id);
-CREATE INDEX IF NOT EXISTS idx_loans_status ON loans(status);
-
--- Audit log indexes
-CREATE INDEX IF NOT EXISTS idx_audit_tenant_date ON audit_logs(tenant_id, created_at);
-CREATE INDEX IF NOT EXISTS idx_audit_entity ON audit_logs(entity_type, entity_id);
-
--- Fraud alert indexes
-CREATE INDEX IF NOT EXISTS idx_fraud_tenant ON fraud_alerts(tenant_id, detected_at);
-CREATE INDEX IF NOT EXISTS idx_fraud_status ON fraud_alerts(status);
-`
+	for _, stmt := range dataset.Postgres.AddPartitions(t, existing, from, to) {
+		if _, err := db.ExecContext(ctx, stmt.SQL); err != nil {
+			return fmt.Errorf("error creating partition %s: %w", stmt.Name, err)
+		}
+		log.Printf("✓ Partition %s created\n", stmt.Name)
+	}
+	return nil
 }
 
 const (
@@ -512,22 +121,124 @@ type SeedConfig struct {
 	Tenants              int
 	CustomersPerTenant   int
 	AccountsPerCustomer  int
-	TransactionsToCreate int // This is the primary target for 1M per run
+	TransactionsToCreate int            // This is the primary target for 1M per run
+	BatchSize            int            // Rows per COPY or multi-VALUES insert
+	Copy                 bool           // Load customers, accounts and transactions with COPY
+	Generators           int            // Goroutines generating transactions
+	Writers              int            // Goroutines writing transaction batches, each on its own connection
+	Seed                 int64          // Seed for every random choice the seeder makes
+	RootSeed             int64          // Seed of the whole sequence of runs, for what stays the same across runs
+	Now                  time.Time      // Frozen clock used for generated dates and created_at
+	Time                 timeline.Model // When transactions happen
+	AccountSkew          float64        // Zipf exponent for picking transaction accounts, 0 for uniform
+	Overdraft            float64        // How far below zero a transaction may take an account, 0 for no overdrafts
+	Weights              string         // Weights file for categorical fields, empty for the built-in weights.yaml
+	KYCPerCustomer       int            // Identity checks per new customer
+	KYCPendingRatio      float64        // Share of checks still pending
+	KYCFailedRatio       float64        // Share of checks that failed
+	MailingAddressRatio  float64        // Share of customers with a separate mailing address
+	DocumentsPerCustomer int            // Uploaded documents per new customer
+	CardRatio            float64        // Share of accounts with a card
+	CardHashKey          string         // HMAC key for card_number_hash, empty for plain SHA-256
+	PANFile              string         // CSV file to append generated card numbers to, empty for none
+	CardTransactions     int            // Card transactions to create per run
+	ForeignCardRatio     float64        // Share of card transactions at merchants abroad
+	LoanRatio            float64        // Share of customers with a loan
+	LatePaymentRatio     float64        // Share of installments paid late, with a penalty
+	PartialPaymentRatio  float64        // Share of installments paid only in part
+	Audit                bool           // Write audit_logs rows for the rows the seeder creates and updates
+	FraudRatio           float64        // Share of transactions and card transactions injected by fraud scenarios, each with a fraud alert
+	LabelAll             bool           // Label every transaction in transaction_metadata, not only injected ones: five rows each
+}
+
+// DefaultSeedConfig returns the configuration used for a standard run
+func DefaultSeedConfig() SeedConfig {
+	// Configuration: Each run creates 1M transactions
+	now := time.Now().UTC()
+	return SeedConfig{
+		Tenants:              10,        // Create 10 tenants if they don't exist
+		CustomersPerTenant:   1000,      // 1K customers per tenant
+		AccountsPerCustomer:  2,         // 2 accounts per customer
+		TransactionsToCreate: 1_000_000, // 1 MILLION transactions per run
+		BatchSize:            BatchSize,
+		Copy:                 true,
+		Generators:           1,
+		Writers:              1,
+		Now:                  now,
+		Time:                 DefaultTimeModel(now),
+		AccountSkew:          1.1,
+		KYCPerCustomer:       1,
+		KYCPendingRatio:      0.08,
+		KYCFailedRatio:       0.03,
+		MailingAddressRatio:  0.25,
+		DocumentsPerCustomer: 2,
+		CardRatio:            0.4,
+		CardTransactions:     100_000,
+		ForeignCardRatio:     0.05,
+		LoanRatio:            0.15,
+		LatePaymentRatio:     0.06,
+		PartialPaymentRatio:  0.02,
+		FraudRatio:           0.002,
+	}
 }
 
-func PerformSeed() {
-	username := "postgres"
-	password := "postgres"
-	host := "localhost"
-	port := 5432
-	database := "banking_db"
+// DefaultTimeModel spreads events over the six months before now, busier
+// on weekdays and during the day
+func DefaultTimeModel(now time.Time) timeline.Model {
+	return timeline.Model{
+		Start:     now.AddDate(0, -6, 0),
+		End:       now,
+		Shape:     timeline.Weekly,
+		Precision: timeline.DefaultPrecision,
+	}
+}
 
-	connStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		host, port, username, password, database,
-	)
+// maxBindParams is the Postgres limit on parameters in a single statement
+const maxBindParams = 65535
 
-	db, err := sql.Open("postgres", connStr)
+// Validate reports configuration values the seeder cannot run with
+func (c SeedConfig) Validate() error {
+	if c.Tenants <= 0 || c.CustomersPerTenant <= 0 || c.AccountsPerCustomer <= 0 {
+		return fmt.Errorf("tenants, customers and accounts must be positive")
+	}
+	if c.TransactionsToCreate < 0 {
+		return fmt.Errorf("transactions must not be negative, got %d", c.TransactionsToCreate)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", c.BatchSize)
+	}
+	if err := c.Time.Validate(); err != nil {
+		return fmt.Errorf("time window: %w", err)
+	}
+	if c.AccountSkew != 0 && c.AccountSkew <= 1 {
+		return fmt.Errorf("account skew must be 0 or above 1, got %v", c.AccountSkew)
+	}
+	if c.Overdraft < 0 {
+		return fmt.Errorf("overdraft must not be negative, got %v", c.Overdraft)
+	}
+	if c.KYCPerCustomer < 0 || c.DocumentsPerCustomer < 0 || c.CardTransactions < 0 {
+		return fmt.Errorf("KYC checks, documents and card transactions must not be negative")
+	}
+	for _, r := range []float64{c.KYCPendingRatio, c.KYCFailedRatio, c.MailingAddressRatio,
+		c.CardRatio, c.ForeignCardRatio, c.LoanRatio, c.LatePaymentRatio, c.PartialPaymentRatio, c.FraudRatio} {
+		if r < 0 || r > 1 {
+			return fmt.Errorf("ratios must be between 0 and 1, got %v", r)
+		}
+	}
+	if c.KYCPendingRatio+c.KYCFailedRatio > 1 {
+		return fmt.Errorf("pending and failed KYC ratios add up to more than 1")
+	}
+	if c.LatePaymentRatio+c.PartialPaymentRatio > 1 {
+		return fmt.Errorf("late and partial payment ratios add up to more than 1")
+	}
+	if c.Generators <= 0 || c.Writers <= 0 {
+		return fmt.Errorf("generators and writers must be positive, got %d and %d", c.Generators, c.Writers)
+	}
+	return nil
+}
+
+func PerformSeed(conn config.Connection, seedConfig SeedConfig) {
+	db, err := sql.Open("pgx", conn.PostgresDSN())
 	if err != nil {
 		log.Printf("error: %v", err.Error())
 		return
@@ -536,14 +247,6 @@ func PerformSeed() {
 
 	ctx := context.Background()
 
-	// Configuration: Each run creates 1M transactions
-	seedConfig := SeedConfig{
-		Tenants:              10,        // Create 10 tenants if they don't exist
-		CustomersPerTenant:   1000,      // 1K customers per tenant
-		AccountsPerCustomer:  2,         // 2 accounts per customer
-		TransactionsToCreate: 1_000_000, // 1 MILLION transactions per run
-	}
-
 	log.Println("Starting data seeding...")
 	log.Printf("Target: %d transactions this run\n", seedConfig.TransactionsToCreate)
 
@@ -556,36 +259,55 @@ func PerformSeed() {
 
 func seedData(ctx context.Context, db *sql.DB, config SeedConfig) error {
 	startTime := time.Now()
+	src := rng.New(config.Seed, config.Now)
+	cats, err := loadCategories(config.Weights)
+	if err != nil {
+		return err
+	}
 
 	// Step 1: Seed tenants (idempotent)
-	tenantIDs, err := seedTenants(ctx, db, config.Tenants)
+	tenantIDs, err := seedTenants(ctx, db, src, cats, config.Tenants)
 	if err != nil {
 		return fmt.Errorf("failed to seed tenants: %w", err)
 	}
 	log.Printf("✓ Tenants ready: %d\n", len(tenantIDs))
+	locales, err := tenantLocales(ctx, db, tenantIDs)
+	if err != nil {
+		return err
+	}
+	var audit *auditLog
+	if config.Audit {
+		if audit, err = newAuditLog(ctx, db, src, locales); err != nil {
+			return err
+		}
+	}
 
 	// Step 2: Seed customers for each tenant
-	customerIDs, err := seedCustomers(ctx, db, tenantIDs, config.CustomersPerTenant)
+	customerIDs, err := seedCustomers(ctx, db, src, audit, locales, tenantIDs, config)
 	if err != nil {
 		return fmt.Errorf("failed to seed customers: %w", err)
 	}
 	log.Printf("✓ Customers seeded: %d\n", len(customerIDs))
 
+	if err := seedCustomerProfiles(ctx, db, src, cats, customerIDs, config); err != nil {
+		return fmt.Errorf("failed to seed KYC and documents: %w", err)
+	}
+
 	// Step 3: Seed accounts for customers
-	accountIDs, err := seedAccounts(ctx, db, customerIDs, config.AccountsPerCustomer)
+	accountIDs, err := seedAccounts(ctx, db, src, cats, audit, locales, customerIDs, config.Time, config.AccountsPerCustomer, config.BatchSize, config.Copy)
 	if err != nil {
 		return fmt.Errorf("failed to seed accounts: %w", err)
 	}
 	log.Printf("✓ Accounts seeded: %d\n", len(accountIDs))
 
 	// Step 4: Seed MASSIVE transactions (1M per run)
-	if err := seedTransactions(ctx, db, accountIDs, config.TransactionsToCreate); err != nil {
+	if err := seedTransactions(ctx, db, src, cats, audit, locales, accountIDs, config); err != nil {
 		return fmt.Errorf("failed to seed transactions: %w", err)
 	}
 	log.Printf("✓ Transactions seeded: %d\n", config.TransactionsToCreate)
 
 	// Step 5: Seed supporting data
-	if err := seedSupportingData(ctx, db, tenantIDs, customerIDs, accountIDs); err != nil {
+	if err := seedSupportingData(ctx, db, src, cats, audit, locales, customerIDs, accountIDs, config); err != nil {
 		return fmt.Errorf("failed to seed supporting data: %w", err)
 	}
 
@@ -598,7 +320,7 @@ func seedData(ctx context.Context, db *sql.DB, config SeedConfig) error {
 }
 
 // seedTenants creates tenants (idempotent - skips existing)
-func seedTenants(ctx context.Context, db *sql.DB, count int) ([]int64, error) {
+func seedTenants(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, count int) ([]int64, error) {
 	log.Println("Seeding tenants...")
 
 	var existingIDs []int64
@@ -623,20 +345,19 @@ func seedTenants(ctx context.Context, db *sql.DB, count int) ([]int64, error) {
 
 	// Create missing tenants
 	needed := count - len(existingIDs)
-	countries := []string{"USA", "GBR", "CAN", "AUS", "IND", "SGP", "DEU", "FRA", "JPN", "BRA"}
 
 	for i := 0; i < needed; i++ {
 		tenantCode := fmt.Sprintf("BANK%04d", len(existingIDs)+i+1)
 		tenantName := fmt.Sprintf("Bank %s", tenantCode)
-		country := countries[rand.Intn(len(countries))]
+		country := cats.countries.Pick(src)
 
 		var tenantID int64
 		err := db.QueryRowContext(ctx, `
-			INSERT INTO tenants (tenant_code, tenant_name, country_code, status)
-			VALUES ($1, $2, $3, 'active')
+			INSERT INTO tenants (tenant_code, tenant_name, country_code, status, created_at, updated_at)
+			VALUES ($1, $2, $3, 'active', $4, $4)
 			ON CONFLICT (tenant_code) DO NOTHING
 			RETURNING tenant_id
-		`, tenantCode, tenantName, country).Scan(&tenantID)
+		`, tenantCode, tenantName, country, src.Now).Scan(&tenantID)
 
 		if err == nil {
 			existingIDs = append(existingIDs, tenantID)
@@ -646,13 +367,65 @@ func seedTenants(ctx context.Context, db *sql.DB, count int) ([]int64, error) {
 	return existingIDs, nil
 }
 
-// seedCustomers creates customers in batches
-func seedCustomers(ctx context.Context, db *sql.DB, tenantIDs []int64, perTenant int) ([]CustomerAccount, error) {
+// tenantLocales looks up the personal data locale of each tenant's country
+func tenantLocales(ctx context.Context, db *sql.DB, tenantIDs []int64) (map[int64]*person.Locale, error) {
+	rows, err := db.QueryContext(ctx, "SELECT tenant_id, country_code FROM tenants WHERE tenant_id = ANY($1)", tenantIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locales := make(map[int64]*person.Locale, len(tenantIDs))
+	for rows.Next() {
+		var id int64
+		var country string
+		if err := rows.Scan(&id, &country); err != nil {
+			return nil, err
+		}
+		if locales[id], err = person.For(country); err != nil {
+			return nil, fmt.Errorf("tenant %d: %w", id, err)
+		}
+	}
+	return locales, rows.Err()
+}
+
+// customerHistory is how long before the time window new customers join,
+// so that their accounts are open before the first transaction
+const customerHistory = 2 * 365 * 24 * time.Hour
+
+// between draws a time between from and to, at precision
+func between(src *rng.Source, from, to time.Time, precision time.Duration) time.Time {
+	t := from
+	if span := to.Sub(from); span > 0 {
+		t = t.Add(time.Duration(src.Int63n(int64(span))))
+	}
+	if truncated := t.Truncate(precision); !truncated.Before(from) {
+		t = truncated
+	}
+	return t
+}
+
+// firstDay is the midnight, in UTC, that starts the first whole day from t
+func firstDay(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if day.Before(t) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// seedCustomers creates customers in batches, each with a primary address
+// and MailingAddressRatio of them with a mailing address. New customers
+// join in the customerHistory before the time window.
+func seedCustomers(ctx context.Context, db *sql.DB, src *rng.Source, audit *auditLog, locales map[int64]*person.Locale, tenantIDs []int64, config SeedConfig) ([]CustomerAccount, error) {
+	perTenant, batchSize := config.CustomersPerTenant, config.BatchSize
 	log.Printf("Seeding %d customers per tenant...\n", perTenant)
+	if err := audit.cover(ctx, config.Time.Start.Add(-customerHistory), config.Time.Start); err != nil {
+		return nil, err
+	}
 
 	var customers []CustomerAccount
-	firstNames := []string{"John", "Jane", "Michael", "Sarah", "David", "Emma", "James", "Olivia", "Robert", "Sophia"}
-	lastNames := []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez"}
 
 	for _, tenantID := range tenantIDs {
 		// Check existing customer count for this tenant
@@ -667,11 +440,12 @@ func seedCustomers(ctx context.Context, db *sql.DB, tenantIDs []int64, perTenant
 		if needed <= 0 {
 			// Load existing customers
 			rows, err := db.QueryContext(ctx, `
-				SELECT customer_id, tenant_id 
+				SELECT customer_id, tenant_id, COALESCE(created_at, $3)
 				FROM customers 
 				WHERE tenant_id = $1 
+				ORDER BY customer_id
 				LIMIT $2
-			`, tenantID, perTenant)
+			`, tenantID, perTenant, src.Now)
 			if err != nil {
 				return nil, err
 			}
@@ -679,7 +453,7 @@ func seedCustomers(ctx context.Context, db *sql.DB, tenantIDs []int64, perTenant
 
 			for rows.Next() {
 				var ca CustomerAccount
-				if err := rows.Scan(&ca.CustomerID, &ca.TenantID); err != nil {
+				if err := rows.Scan(&ca.CustomerID, &ca.TenantID, &ca.Since); err != nil {
 					return nil, err
 				}
 				customers = append(customers, ca)
@@ -688,327 +462,684 @@ func seedCustomers(ctx context.Context, db *sql.DB, tenantIDs []int64, perTenant
 		}
 
 		// Batch insert new customers
-		for batch := 0; batch < needed; batch += BatchSize {
-			batchEnd := batch + BatchSize
-			if batchEnd > needed {
-				batchEnd = needed
+		for batch := 0; batch < needed; batch += batchSize {
+			n := min(batchSize, needed-batch)
+			var created []CustomerAccount
+			req := customerBatch{locale: locales[tenantID], tenantID: tenantID, existing: existingCount + batch,
+				count: n, mailingRatio: config.MailingAddressRatio, window: config.Time, audit: audit}
+			if config.Copy {
+				created, err = copyCustomers(ctx, db, src, req)
+			} else {
+				created, err = insertCustomers(ctx, db, src, req)
 			}
-
-			tx, err := db.BeginTx(ctx, nil)
 			if err != nil {
 				return nil, err
 			}
+			customers = append(customers, created...)
+		}
 
-			valueStrings := []string{}
-			valueArgs := []interface{}{}
-			argPos := 1
-
-			for i := batch; i < batchEnd; i++ {
-				customerCode := fmt.Sprintf("CUST%d%06d", tenantID, existingCount+i+1)
-				firstName := firstNames[rand.Intn(len(firstNames))]
-				lastName := lastNames[rand.Intn(len(lastNames))]
-				email := fmt.Sprintf("%s.%s%d@email.com",
-					strings.ToLower(firstName),
-					strings.ToLower(lastName),
-					rand.Intn(1000))
-				phone := fmt.Sprintf("+1555%07d", rand.Intn(10000000))
-
-				valueStrings = append(valueStrings,
-					fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-						argPos, argPos+1, argPos+2, argPos+3, argPos+4, argPos+5, argPos+6))
-
-				valueArgs = append(valueArgs, tenantID, customerCode, firstName, lastName,
-					email, phone, "active")
-				argPos += 7
-			}
+		log.Printf("  Tenant %d: %d customers ready\n", tenantID, perTenant)
+	}
 
-			query := fmt.Sprintf(`
-				INSERT INTO customers (tenant_id, customer_code, first_name, last_name, email, phone, status)
-				VALUES %s
-				RETURNING customer_id, tenant_id
-			`, strings.Join(valueStrings, ","))
+	return customers, nil
+}
 
-			rows, err := tx.QueryContext(ctx, query, valueArgs...)
-			if err != nil {
-				tx.Rollback()
-				return nil, err
-			}
+// customerFields generates tenant_id through status for the seq-th customer
+// of a tenant. The customer id keeps the email unique.
+func customerFields(src *rng.Source, locale *person.Locale, tenantID, customerID int64, seq int) []any {
+	customerCode := fmt.Sprintf("CUST%d%06d", tenantID, seq)
+	p := locale.Person(src, customerID)
 
-			for rows.Next() {
-				var ca CustomerAccount
-				if err := rows.Scan(&ca.CustomerID, &ca.TenantID); err != nil {
-					rows.Close()
-					tx.Rollback()
-					return nil, err
-				}
-				customers = append(customers, ca)
-			}
-			rows.Close()
+	return []any{tenantID, customerCode, p.FirstName, p.LastName, p.Email, p.Phone,
+		p.DateOfBirth, p.Nationality, "active"}
+}
 
-			if err := tx.Commit(); err != nil {
-				return nil, err
-			}
-		}
+// addressFields generates a customer_addresses row of addressCopyColumns,
+// created at createdAt
+func addressFields(src *rng.Source, locale *person.Locale, customerID, tenantID int64, addressType string, createdAt time.Time) []any {
+	a := locale.Address(src)
+	var line2 any
+	if a.Line2 != "" {
+		line2 = a.Line2
+	}
+	return []any{customerID, tenantID, addressType, a.Line1, line2,
+		a.City, a.State, a.PostalCode, a.Country, createdAt, createdAt}
+}
 
-		log.Printf("  Tenant %d: %d customers ready\n", tenantID, perTenant)
+// customerBatch asks for count new customers of a tenant, numbered after
+// existing
+type customerBatch struct {
+	locale       *person.Locale
+	tenantID     int64
+	existing     int
+	count        int
+	mailingRatio float64 // share with a mailing address besides the primary one
+	window       timeline.Model
+	audit        *auditLog
+}
+
+// audited audits the creation of the customers with ids
+func (b customerBatch) audited(ids []int64) auditStatement {
+	tenantIDs := make([]int64, len(ids))
+	for i := range tenantIDs {
+		tenantIDs[i] = b.tenantID
 	}
+	return b.audit.created("customers", "customer_id", ids, tenantIDs)
+}
+
+// rows builds the customers rows and address rows for the reserved ids
+func (b customerBatch) rows(src *rng.Source, ids []int64) (rows, addresses [][]any, customers []CustomerAccount) {
+	for i, id := range ids {
+		joined := between(src, b.window.Start.Add(-customerHistory), b.window.Start, b.window.Precision)
+		row := append([]any{id}, customerFields(src, b.locale, b.tenantID, id, b.existing+i+1)...)
+		rows = append(rows, append(row, joined, joined))
+		addresses = append(addresses, addressFields(src, b.locale, id, b.tenantID, "primary", joined))
+		if src.Float64() < b.mailingRatio {
+			addresses = append(addresses, addressFields(src, b.locale, id, b.tenantID, "mailing", joined))
+		}
+		customers = append(customers, CustomerAccount{CustomerID: id, TenantID: b.tenantID, Since: joined})
+	}
+	return rows, addresses, customers
+}
 
+// copyCustomers loads a batch of customers and their addresses with two
+// COPYs in one transaction
+func copyCustomers(ctx context.Context, db *sql.DB, src *rng.Source, req customerBatch) ([]CustomerAccount, error) {
+	var customers []CustomerAccount
+	err := withCopyTx(ctx, db, func(tx pgx.Tx) error {
+		ids, err := reserveIDs(ctx, tx, "customers", "customer_id", req.count)
+		if err != nil {
+			return err
+		}
+
+		var rows, addresses [][]any
+		rows, addresses, customers = req.rows(src, ids)
+		if err := copyRows(ctx, tx, "customers", customerCopyColumns, rows); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "customer_addresses", addressCopyColumns, addresses); err != nil {
+			return err
+		}
+		return req.audited(ids).copyTx(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
+	}
 	return customers, nil
 }
 
+// insertCustomers is copyCustomers with multi-VALUES INSERTs
+func insertCustomers(ctx context.Context, db *sql.DB, src *rng.Source, req customerBatch) ([]CustomerAccount, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := nextIDs(ctx, tx, "customers", "customer_id", req.count)
+	if err != nil {
+		return nil, err
+	}
+	rows, addresses, customers := req.rows(src, ids)
+	if err := insertRows(ctx, tx, "customers", customerCopyColumns, rows); err != nil {
+		return nil, err
+	}
+	if err := insertRows(ctx, tx, "customer_addresses", addressCopyColumns, addresses); err != nil {
+		return nil, err
+	}
+	if err := req.audited(ids).sqlTx(ctx, tx); err != nil {
+		return nil, err
+	}
+	return customers, tx.Commit()
+}
+
 type CustomerAccount struct {
 	CustomerID int64
 	TenantID   int64
 	AccountIDs []int64
+	Since      time.Time // created_at of the customer
 }
 
-// seedAccounts creates accounts for customers
-func seedAccounts(ctx context.Context, db *sql.DB, customers []CustomerAccount, perCustomer int) ([]AccountInfo, error) {
+// seedAccounts creates accounts for customers, numbered by the bank each
+// tenant operates as after the tenant's existing accounts. New accounts
+// open between the customer joining and the start of the time window.
+func seedAccounts(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, audit *auditLog, locales map[int64]*person.Locale, customers []CustomerAccount, window timeline.Model, perCustomer, batchSize int, useCopy bool) ([]AccountInfo, error) {
 	log.Printf("Seeding %d accounts per customer...\n", perCustomer)
 
+	banks, err := tenantBanks(locales)
+	if err != nil {
+		return nil, err
+	}
+	serials, err := accountCounts(ctx, db)
+	if err != nil {
+		return nil, err
+	}
 	var accounts []AccountInfo
-	accountTypes := []string{"checking", "savings", "money_market", "credit"}
-	currencies := []string{"USD", "EUR", "GBP", "CAD"}
+	// Customers still short of accounts, flushed every batchSize accounts
+	req := accountBatch{banks: banks, serials: serials, window: window, audit: audit}
+	flush := func() error {
+		var created []AccountInfo
+		var err error
+		if useCopy {
+			created, err = copyAccounts(ctx, db, src, cats, req)
+		} else {
+			created, err = insertAccounts(ctx, db, src, cats, req)
+		}
+		accounts = append(accounts, created...)
+		req.requests, req.count = req.requests[:0], 0
+		return err
+	}
 
 	for idx, customer := range customers {
 		// Check existing accounts
-		var existingAccounts []int64
+		var existingAccounts []AccountInfo
 		rows, err := db.QueryContext(ctx, `
-			SELECT a.account_id 
+			SELECT a.account_id, COALESCE(a.created_at, $3)
 			FROM accounts a
 			JOIN account_holders ah ON a.account_id = ah.account_id
 			WHERE ah.customer_id = $1
+			ORDER BY a.account_id
 			LIMIT $2
-		`, customer.CustomerID, perCustomer)
+		`, customer.CustomerID, perCustomer, src.Now)
 
 		if err != nil {
 			return nil, err
 		}
 
 		for rows.Next() {
-			var accountID int64
-			if err := rows.Scan(&accountID); err != nil {
+			account := AccountInfo{TenantID: customer.TenantID, CustomerID: customer.CustomerID}
+			if err := rows.Scan(&account.AccountID, &account.Opened); err != nil {
 				rows.Close()
 				return nil, err
 			}
-			existingAccounts = append(existingAccounts, accountID)
+			existingAccounts = append(existingAccounts, account)
 		}
 		rows.Close()
 
 		// Add existing to result
-		for _, accID := range existingAccounts {
-			accounts = append(accounts, AccountInfo{
-				AccountID:  accID,
-				TenantID:   customer.TenantID,
-				CustomerID: customer.CustomerID,
-			})
-		}
+		accounts = append(accounts, existingAccounts...)
 
 		needed := perCustomer - len(existingAccounts)
 		if needed <= 0 {
 			continue
 		}
 
-		// Create new accounts
-		tx, err := db.BeginTx(ctx, nil)
-		if err != nil {
+		req.requests = append(req.requests, accountRequest{customer: customer, count: needed})
+		req.count += needed
+		if req.count >= batchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			log.Printf("  Processed %d/%d customers\n", idx+1, len(customers))
+		}
+	}
+
+	if req.count > 0 {
+		if err := flush(); err != nil {
 			return nil, err
 		}
+	}
 
-		for i := 0; i < needed; i++ {
-			accountNumber := fmt.Sprintf("%d%010d", customer.TenantID, rand.Int63n(10000000000))
-			accountType := accountTypes[rand.Intn(len(accountTypes))]
-			currency := currencies[rand.Intn(len(currencies))]
+	return accounts, nil
+}
 
-			var accountID int64
-			err := tx.QueryRowContext(ctx, `
-				INSERT INTO accounts (tenant_id, account_number, account_type, currency_code, status)
-				VALUES ($1, $2, $3, $4, 'active')
-				RETURNING account_id
-			`, customer.TenantID, accountNumber, accountType, currency).Scan(&accountID)
+// tenantBanks assigns each tenant a bank of its country's registry, the
+// same one on every run
+func tenantBanks(locales map[int64]*person.Locale) (map[int64]*bank.Bank, error) {
+	banks := make(map[int64]*bank.Bank, len(locales))
+	for tenantID, locale := range locales {
+		registry, err := bank.In(locale.Country)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %d: %w", tenantID, err)
+		}
+		banks[tenantID] = registry[(tenantID-1)%int64(len(registry))]
+	}
+	return banks, nil
+}
 
-			if err != nil {
-				tx.Rollback()
-				return nil, err
-			}
+// accountCounts counts the accounts of each tenant
+func accountCounts(ctx context.Context, db *sql.DB) (map[int64]int64, error) {
+	rows, err := db.QueryContext(ctx, "SELECT tenant_id, COUNT(*) FROM accounts GROUP BY tenant_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-			// Link to customer
-			_, err = tx.ExecContext(ctx, `
-				INSERT INTO account_holders (account_id, customer_id, tenant_id, holder_type)
-				VALUES ($1, $2, $3, 'primary')
-			`, accountID, customer.CustomerID, customer.TenantID)
+	counts := map[int64]int64{}
+	for rows.Next() {
+		var tenantID, count int64
+		if err := rows.Scan(&tenantID, &count); err != nil {
+			return nil, err
+		}
+		counts[tenantID] = count
+	}
+	return counts, rows.Err()
+}
 
-			if err != nil {
-				tx.Rollback()
-				return nil, err
-			}
+// accountFields generates tenant_id, account_number, account_type and
+// currency_code for the account with serial at a tenant's bank. Serials
+// count the tenant's accounts, which keeps the number unique within it.
+func accountFields(src *rng.Source, cats *categories, home *bank.Bank, tenantID, serial int64) ([]any, error) {
+	accountNumber, err := home.Account(src, serial)
+	if err != nil {
+		return nil, err
+	}
+	accountType := cats.accountTypes.Pick(src)
+	currency := cats.currencies.Pick(src)
+
+	return []any{tenantID, accountNumber, accountType, currency}, nil
+}
 
-			// Initialize balance
-			initialBalance := float64(rand.Intn(100000))
-			_, err = tx.ExecContext(ctx, `
-				INSERT INTO account_balances (account_id, tenant_id, available_balance, current_balance)
-				VALUES ($1, $2, $3, $3)
-			`, accountID, customer.TenantID, initialBalance)
+// accountRequest asks for count new accounts held by customer
+type accountRequest struct {
+	customer CustomerAccount
+	count    int
+}
+
+// accountBatch is the account requests of one write; count is their total
+type accountBatch struct {
+	banks    map[int64]*bank.Bank // by tenant
+	serials  map[int64]int64      // next account serial, by tenant
+	window   timeline.Model       // accounts open before it starts
+	audit    *auditLog
+	requests []accountRequest
+	count    int
+}
 
+// audited audits the opening of accounts
+func (b accountBatch) audited(accounts []AccountInfo) auditStatement {
+	ids := make([]int64, len(accounts))
+	tenantIDs := make([]int64, len(accounts))
+	for i, a := range accounts {
+		ids[i], tenantIDs[i] = a.AccountID, a.TenantID
+	}
+	return b.audit.created("accounts", "account_id", ids, tenantIDs)
+}
+
+// rows builds the accounts rows, holder rows and opening balance rows for
+// the reserved ids
+func (b accountBatch) rows(src *rng.Source, cats *categories, ids []int64) (rows, holders, balances [][]any, accounts []AccountInfo, err error) {
+	for _, req := range b.requests {
+		customer := req.customer
+		for range req.count {
+			id := ids[len(rows)]
+			serial := b.serials[customer.TenantID]
+			b.serials[customer.TenantID]++
+			fields, err := accountFields(src, cats, b.banks[customer.TenantID], customer.TenantID, serial)
 			if err != nil {
-				tx.Rollback()
-				return nil, err
+				return nil, nil, nil, nil, err
 			}
+			opened := between(src, customer.Since, b.window.Start, b.window.Precision)
+			row := append([]any{id}, fields...)
+			rows = append(rows, append(row, "active", opened, opened, opened))
+			holders = append(holders, []any{id, customer.CustomerID, customer.TenantID, "primary", opened})
+
+			initialBalance := float64(src.Intn(100000))
+			balances = append(balances, []any{id, customer.TenantID, initialBalance, initialBalance, opened})
 
 			accounts = append(accounts, AccountInfo{
-				AccountID:  accountID,
+				AccountID:  id,
 				TenantID:   customer.TenantID,
 				CustomerID: customer.CustomerID,
+				Opened:     opened,
 			})
 		}
+	}
+	return rows, holders, balances, accounts, nil
+}
 
-		if err := tx.Commit(); err != nil {
-			return nil, err
+// copyAccounts creates the requested accounts, with their holder and opening
+// balance rows, in one transaction of three COPYs
+func copyAccounts(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, req accountBatch) ([]AccountInfo, error) {
+	var accounts []AccountInfo
+	err := withCopyTx(ctx, db, func(tx pgx.Tx) error {
+		ids, err := reserveIDs(ctx, tx, "accounts", "account_id", req.count)
+		if err != nil {
+			return err
 		}
 
-		if (idx+1)%1000 == 0 {
-			log.Printf("  Processed %d/%d customers\n", idx+1, len(customers))
+		var rows, holders, balances [][]any
+		if rows, holders, balances, accounts, err = req.rows(src, cats, ids); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "accounts", accountCopyColumns, rows); err != nil {
+			return err
+		}
+		if err := copyRows(ctx, tx, "account_holders", accountHolderCopyColumns, holders); err != nil {
+			return err
 		}
+		if err := copyRows(ctx, tx, "account_balances", accountBalanceCopyColumns, balances); err != nil {
+			return err
+		}
+		return req.audited(accounts).copyTx(ctx, tx)
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return accounts, nil
 }
 
+// insertAccounts is copyAccounts with multi-VALUES INSERTs
+func insertAccounts(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, req accountBatch) ([]AccountInfo, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := nextIDs(ctx, tx, "accounts", "account_id", req.count)
+	if err != nil {
+		return nil, err
+	}
+	rows, holders, balances, accounts, err := req.rows(src, cats, ids)
+	if err != nil {
+		return nil, err
+	}
+	if err := insertRows(ctx, tx, "accounts", accountCopyColumns, rows); err != nil {
+		return nil, err
+	}
+	if err := insertRows(ctx, tx, "account_holders", accountHolderCopyColumns, holders); err != nil {
+		return nil, err
+	}
+	if err := insertRows(ctx, tx, "account_balances", accountBalanceCopyColumns, balances); err != nil {
+		return nil, err
+	}
+	if err := req.audited(accounts).sqlTx(ctx, tx); err != nil {
+		return nil, err
+	}
+	return accounts, tx.Commit()
+}
+
 type AccountInfo struct {
 	AccountID  int64
 	TenantID   int64
 	CustomerID int64
+	Opened     time.Time // created_at of the account
 }
 
-// seedTransactions creates MASSIVE transaction data (1M per run)
-func seedTransactions(ctx context.Context, db *sql.DB, accounts []AccountInfo, count int) error {
+// seedTransactions creates MASSIVE transaction data (1M per run).
+// FraudRatio of them come from fraud scenarios and are alerted in
+// fraud_alerts and labelled in transaction_metadata with their fraud_label
+// and origin, as are all the rest with LabelAll. See planFraud.
+func seedTransactions(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, audit *auditLog, locales map[int64]*person.Locale, accounts []AccountInfo, config SeedConfig) error {
+	count := config.TransactionsToCreate
 	log.Printf("Seeding %d transactions...\n", count)
 
 	if len(accounts) < 2 {
 		return fmt.Errorf("need at least 2 accounts to create transactions")
 	}
+	if count == 0 {
+		return nil
+	}
 
-	transactionTypes := []string{"transfer", "deposit", "withdrawal", "payment", "refund"}
-	statuses := []string{"completed", "completed", "completed", "pending", "failed"}
+	if err := ensurePartitions(ctx, db, "transactions", config.Time.Start, config.Time.End); err != nil {
+		return err
+	}
+	model, err := newTransactionModel(accounts, cats, config.AccountSkew, config.Time)
+	if err != nil {
+		return err
+	}
+	// Transaction n of the run takes transaction_ref refBase+n, so parallel
+	// generators never collide
+	refBase := src.Int63n(1e13 - int64(count))
+	origins, err := usualOrigins(config.RootSeed, accounts, locales)
+	if err != nil {
+		return err
+	}
+	fraud, err := planFraud(src, &model, locales, origins, count, config.FraudRatio)
+	if err != nil {
+		return fmt.Errorf("fraud scenarios: %w", err)
+	}
+	book, err := newLedger(ctx, db, src, accounts, config.Time, count, config.Generators, config.Overdraft, fraud.txns)
+	if err != nil {
+		return err
+	}
 
-	startDate := time.Now().AddDate(0, -6, 0) // Start 6 months ago
+	srcs := make([]*rng.Source, config.Generators)
+	for g := range srcs {
+		srcs[g] = src
+		if config.Generators > 1 {
+			srcs[g] = src.Fork(int64(g))
+		}
+	}
 
-	processed := 0
-	for batch := 0; batch < count; batch += BatchSize {
-		batchEnd := batch + BatchSize
-		if batchEnd > count {
-			batchEnd = count
+	// Generators take batches round-robin and post them in turn
+	batches := (count + config.BatchSize - 1) / config.BatchSize
+	produce := func(ctx context.Context, g int, out chan<- transactionBatch) error {
+		for b := g; b < batches; b += config.Generators {
+			start := b * config.BatchSize
+			n := min(config.BatchSize, count-start)
+			srcs[g].IDs = rng.IDRange{Next: refBase + int64(start), End: refBase + int64(start+n)}
+			rows := make([][]any, n)
+			var injected []injection
+			for i := range rows {
+				if f := book.injection(start + i); f != nil {
+					rows[i] = f.draft(srcs[g])
+					injected = append(injected, injection{txn: i, fraud: f})
+				} else {
+					rows[i] = draftTransaction(srcs[g], &model)
+				}
+			}
+			legs, err := book.post(ctx, g, start, rows)
+			if err != nil {
+				return err
+			}
+			if err := pool.Send(ctx, out, transactionBatch{
+				rows: rows, legs: legs, injected: injected, origins: origins, labelAll: config.LabelAll,
+				audit: audit, now: src.Now,
+			}); err != nil {
+				return err
+			}
 		}
+		return nil
+	}
 
-		tx, err := db.BeginTx(ctx, nil)
-		if err != nil {
-			return err
+	var processed, posted atomic.Int64
+	consume := func(ctx context.Context, _ int, in <-chan transactionBatch) error {
+		for batch := range in {
+			if config.Copy {
+				if err := copyTransactions(ctx, db, batch); err != nil {
+					return err
+				}
+			} else if err := insertTransactions(ctx, db, batch); err != nil {
+				return err
+			}
+			posted.Add(int64(len(batch.legs)))
+
+			total := processed.Add(int64(len(batch.rows)))
+			if total/50000 != (total-int64(len(batch.rows)))/50000 {
+				log.Printf("  Progress: %d/%d (%.1f%%)\n", total, count, float64(total)/float64(count)*100)
+			}
 		}
+		return nil
+	}
+	if err := pool.Run(ctx, config.Generators, config.Writers, 2*config.Writers, produce, consume); err != nil {
+		return err
+	}
+
+	log.Printf("  ✓ %d transactions completed, %d legs posted to %d accounts\n", count, posted.Load(), len(book.posted))
+	if fraud.episodes > 0 {
+		log.Printf("  ✓ Injected %d fraud transactions in %d episodes, each with a fraud alert\n", len(fraud.txns), fraud.episodes)
+	}
+	return nil
+}
 
-		valueStrings := []string{}
-		valueArgs := []interface{}{}
-		argPos := 1
+// transactionModel is what transactionFields draws a transaction from
+type transactionModel struct {
+	accounts []AccountInfo
+	account  distribution.Distribution // index into accounts
+	amount   distribution.Distribution
+	window   timeline.Model
+	cats     *categories
+}
 
-		for i := batch; i < batchEnd; i++ {
-			// Random accounts
-			fromAccount := accounts[rand.Intn(len(accounts))]
-			toAccount := accounts[rand.Intn(len(accounts))]
+// newTransactionModel picks accounts by Zipf with exponent skew, so a few
+// accounts are hot, or uniformly when skew is 0. Amounts are log-normal
+// around a $45 median with a long tail up to $10,000.
+func newTransactionModel(accounts []AccountInfo, cats *categories, skew float64, window timeline.Model) (transactionModel, error) {
+	pick := distribution.Params{}
+	if skew != 0 {
+		pick = distribution.Params{Dist: "zipf", S: skew}
+	}
+	account, err := pick.Index(len(accounts))
+	if err != nil {
+		return transactionModel{}, fmt.Errorf("account skew: %w", err)
+	}
+	return transactionModel{
+		accounts: accounts,
+		account:  account,
+		amount:   distribution.Clamp(distribution.LogNormal(math.Log(45), 1.2), 0.01, 10000),
+		window:   window,
+		cats:     cats,
+	}, nil
+}
 
-			// Ensure different accounts
-			for toAccount.AccountID == fromAccount.AccountID {
-				toAccount = accounts[rand.Intn(len(accounts))]
-			}
+// pickAccount draws an account index. Accounts closed since the model was
+// built shrink the list, so draws past its end land on the last account.
+func (m *transactionModel) pickAccount(src *rng.Source) AccountInfo {
+	return m.accounts[min(int(m.account.Sample(src)), len(m.accounts)-1)]
+}
 
-			txnRef := fmt.Sprintf("TXN%d%013d", fromAccount.TenantID, time.Now().UnixNano()+int64(i))
-			txnType := transactionTypes[rand.Intn(len(transactionTypes))]
-			amount := float64(rand.Intn(100000)) / 100.0 // $0.01 to $1000.00
-			status := statuses[rand.Intn(len(statuses))]
-
-			// Random date in the past 6 months
-			randomDays := rand.Intn(180)
-			txnDate := startDate.AddDate(0, 0, randomDays)
-
-			valueStrings = append(valueStrings,
-				fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-					argPos, argPos+1, argPos+2, argPos+3, argPos+4,
-					argPos+5, argPos+6, argPos+7, argPos+8, argPos+9))
-
-			valueArgs = append(valueArgs,
-				fromAccount.TenantID,
-				txnRef,
-				fromAccount.AccountID,
-				toAccount.AccountID,
-				txnType,
-				amount,
-				"USD",
-				status,
-				fmt.Sprintf("%s transaction", txnType),
-				txnDate,
-			)
-			argPos += 10
-		}
+// transactionFields generates tenant_id through transaction_date for a
+// transaction between two distinct accounts
+func transactionFields(src *rng.Source, m *transactionModel) []any {
+	return append(draftTransaction(src, m), m.window.Draw(src))
+}
 
-		query := fmt.Sprintf(`
-			INSERT INTO transactions 
-			(tenant_id, transaction_ref, from_account_id, to_account_id, transaction_type, 
-			 amount, currency_code, status, description, transaction_date)
-			VALUES %s
-		`, strings.Join(valueStrings, ","))
+// draftTransaction is transactionFields without the transaction_date
+func draftTransaction(src *rng.Source, m *transactionModel) []any {
+	fromAccount := m.pickAccount(src)
+	toAccount := m.pickAccount(src)
 
-		_, err = tx.ExecContext(ctx, query, valueArgs...)
+	// Ensure different accounts
+	for toAccount.AccountID == fromAccount.AccountID {
+		toAccount = m.pickAccount(src)
+	}
+
+	txnRef := transactionRef(src, fromAccount.TenantID)
+	txnType := m.cats.transactionTypes.Pick(src)
+	amount := math.Round(m.amount.Sample(src)*100) / 100
+	status := m.cats.transactionStatus.Pick(src, txnType)
+
+	return transactionRow(txnRef, fromAccount, toAccount, txnType, amount, status)
+}
+
+// transactionRef numbers a transaction of a tenant with the next
+// identifier reserved for src
+func transactionRef(src *rng.Source, tenantID int64) string {
+	return fmt.Sprintf("TXN%d%013d", tenantID, src.IDs.Take())
+}
+
+// transactionRow is the draftTransaction row of a transaction with ref
+func transactionRow(ref string, from, to AccountInfo, txnType string, amount float64, status string) []any {
+	return []any{
+		from.TenantID,
+		ref,
+		from.AccountID,
+		to.AccountID,
+		txnType,
+		amount,
+		"USD",
+		status,
+		fmt.Sprintf("%s transaction", txnType),
+	}
+}
+
+// withIDs numbers the batch with reserved transaction ids and builds the
+// transactions and transaction_legs rows
+func (b transactionBatch) withIDs(ids []int64) (rows, legs [][]any) {
+	rows = make([][]any, len(b.rows))
+	for i, row := range b.rows {
+		// created_at and updated_at are the transaction_date
+		txnDate := row[len(row)-1]
+		rows[i] = append(append([]any{ids[i]}, row...), txnDate, txnDate)
+	}
+	legs = make([][]any, len(b.legs))
+	for i, l := range b.legs {
+		legs[i] = append([]any{ids[l.txn]}, l.row...)
+	}
+	return rows, legs
+}
+
+// copyTransactions loads a batch of transactions, their legs and metadata
+// and the alerts of the injected ones with COPYs, and moves the balances
+// the legs post to, in one transaction
+func copyTransactions(ctx context.Context, db *sql.DB, batch transactionBatch) error {
+	return withCopyTx(ctx, db, func(tx pgx.Tx) error {
+		ids, err := reserveIDs(ctx, tx, "transactions", "transaction_id", len(batch.rows))
 		if err != nil {
-			tx.Rollback()
-			return fmt.Errorf("batch insert failed: %w", err)
+			return err
 		}
-
-		if err := tx.Commit(); err != nil {
+		rows, legs := batch.withIDs(ids)
+		metadata, alerts := batch.labels(ids)
+		if err := copyRows(ctx, tx, "transactions", transactionCopyColumns, rows); err != nil {
 			return err
 		}
-
-		processed += (batchEnd - batch)
-		if processed%50000 == 0 {
-			log.Printf("  Progress: %d/%d (%.1f%%)\n", processed, count, float64(processed)/float64(count)*100)
+		if err := copyRows(ctx, tx, "transaction_legs", transactionLegCopyColumns, legs); err != nil {
+			return err
+		}
+		if err := batch.copyBalances(ctx, tx); err != nil {
+			return err
 		}
+		if err := copyRows(ctx, tx, "transaction_metadata", transactionMetadataCopyColumns, metadata); err != nil {
+			return err
+		}
+		return copyRows(ctx, tx, "fraud_alerts", fraudAlertCopyColumns, alerts)
+	})
+}
+
+// insertTransactions is copyTransactions with multi-VALUES INSERTs
+func insertTransactions(ctx context.Context, db *sql.DB, batch transactionBatch) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
+	defer tx.Rollback()
 
-	log.Printf("  ✓ %d transactions completed\n", count)
-	return nil
+	ids, err := nextIDs(ctx, tx, "transactions", "transaction_id", len(batch.rows))
+	if err != nil {
+		return err
+	}
+	rows, legs := batch.withIDs(ids)
+	metadata, alerts := batch.labels(ids)
+	if err := insertRows(ctx, tx, "transactions", transactionCopyColumns, rows); err != nil {
+		return err
+	}
+	if err := insertRows(ctx, tx, "transaction_legs", transactionLegCopyColumns, legs); err != nil {
+		return err
+	}
+	if err := batch.insertBalances(ctx, tx); err != nil {
+		return err
+	}
+	if err := insertRows(ctx, tx, "transaction_metadata", transactionMetadataCopyColumns, metadata); err != nil {
+		return err
+	}
+	if err := insertRows(ctx, tx, "fraud_alerts", fraudAlertCopyColumns, alerts); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// seedSupportingData creates cards, KYC, etc.
-func seedSupportingData(ctx context.Context, db *sql.DB, _ []int64, _ []CustomerAccount, accounts []AccountInfo) error {
+// seedSupportingData creates cards, beneficiaries, KYC, etc.
+func seedSupportingData(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, audit *auditLog, locales map[int64]*person.Locale, customers []CustomerAccount, accounts []AccountInfo, config SeedConfig) error {
 	log.Println("Seeding supporting data...")
 
-	// Seed some cards (10% of accounts)
-	cardCount := min(len(accounts)/10,
-		// Limit for demo
-		1000)
-
-	for range cardCount {
-		account := accounts[rand.Intn(len(accounts))]
-		lastFour := fmt.Sprintf("%04d", rand.Intn(10000))
-		cardTypes := []string{"debit", "credit"}
-		brands := []string{"visa", "mastercard", "amex"}
-
-		_, err := db.ExecContext(ctx, `
-			INSERT INTO cards (tenant_id, account_id, customer_id, card_number_hash, 
-				card_last_four, card_type, card_brand, expiry_month, expiry_year, status)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'active')
-			ON CONFLICT DO NOTHING
-		`, account.TenantID, account.AccountID, account.CustomerID,
-			fmt.Sprintf("hash_%d", rand.Int63()),
-			lastFour,
-			cardTypes[rand.Intn(len(cardTypes))],
-			brands[rand.Intn(len(brands))],
-			rand.Intn(12)+1,
-			time.Now().Year()+rand.Intn(5))
+	beneficiaries, err := seedBeneficiaries(ctx, db, src, locales, customers, config.BatchSize, config.Copy)
+	if err != nil {
+		return fmt.Errorf("beneficiaries: %w", err)
+	}
+	log.Printf("  ✓ Created %d beneficiaries\n", beneficiaries)
 
-		if err != nil {
-			log.Printf("Warning: card insert failed: %v\n", err)
-		}
+	if err := seedLoans(ctx, db, src, cats, audit, accounts, config); err != nil {
+		return fmt.Errorf("loans: %w", err)
 	}
 
-	log.Printf("  ✓ Created %d cards\n", cardCount)
+	if err := seedCards(ctx, db, src, cats, accounts, config); err != nil {
+		return fmt.Errorf("cards: %w", err)
+	}
+	if err := seedCardTransactions(ctx, db, src, cats, locales, config); err != nil {
+		return fmt.Errorf("card transactions: %w", err)
+	}
 	return nil
 }
 
@@ -1132,3 +1263,71 @@ CREATE TABLE IF NOT EXISTS metrics.flow_runs (
 CREATE INDEX IF NOT EXISTS idx_flow_runs_flow ON metrics.flow_runs(flow_id, start_time DESC);
 `
 }
+
+// beneficiaryShare is the share of customers who keep saved payees
+const beneficiaryShare = 0.2
+
+// foreignPayeeShare is the share of payees in another country
+const foreignPayeeShare = 0.1
+
+// seedBeneficiaries gives beneficiaryShare of the customers without payees
+// one to three, named after people of their own or, now and then, another
+// country, and banking with a bank of that country
+func seedBeneficiaries(ctx context.Context, db *sql.DB, src *rng.Source, locales map[int64]*person.Locale, customers []CustomerAccount, batchSize int, useCopy bool) (int, error) {
+	existing, err := customersWith(ctx, db, "beneficiaries")
+	if err != nil {
+		return 0, err
+	}
+	countries := person.Codes()
+
+	batch := newRowBatcher(db, useCopy, "beneficiaries", beneficiaryCopyColumns, batchSize)
+	for _, customer := range customers {
+		if existing[customer.CustomerID] || src.Float64() >= beneficiaryShare {
+			continue
+		}
+		for range 1 + src.Intn(3) {
+			locale := locales[customer.TenantID]
+			if src.Float64() < foreignPayeeShare {
+				if locale, err = person.For(countries[src.Intn(len(countries))]); err != nil {
+					return batch.written, err
+				}
+			}
+			first, last := locale.Name(src)
+			payee, err := bank.Pick(src, locale.Country)
+			if err != nil {
+				return batch.written, err
+			}
+			err = batch.add(ctx, []any{customer.TenantID, customer.CustomerID, first + " " + last,
+				payee.RandomAccount(src), payee.Code, payee.Name, "active", src.Now, src.Now})
+			if err != nil {
+				return batch.written, err
+			}
+		}
+	}
+	err = batch.flush(ctx)
+	return batch.written, err
+}
+
+// customersWith returns the customers that already have rows in table
+func customersWith(ctx context.Context, db *sql.DB, table string) (map[int64]bool, error) {
+	return idsIn(ctx, db, table, "customer_id")
+}
+
+// idsIn returns the distinct values of column in table
+func idsIn(ctx context.Context, db *sql.DB, table, column string) (map[int64]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT DISTINCT "+column+" FROM "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
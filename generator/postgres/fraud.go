@@ -0,0 +1,461 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"datagenerator/generator/geo"
+	"datagenerator/generator/merchant"
+	"datagenerator/generator/person"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/timeline"
+)
+
+const (
+	// fraudStream and cardFraudStream fork the sources of the fraud plans
+	// of transactions and card transactions
+	fraudStream     = 0xf4a0d
+	cardFraudStream = 0xc4a0d
+	// alertTriage is how long a new alert stays open before an analyst
+	// picks it up, and alertReview how long until the fraud is confirmed
+	alertTriage = 12 * time.Hour
+	alertReview = 72 * time.Hour
+	// travelKm is the least distance between the places of an impossible
+	// travel episode
+	travelKm = 3000
+)
+
+// customerAgents are the clients customers bank with
+var customerAgents = []string{
+	"BankApp/5.14.2 (iPhone; iOS 17.4.1; Scale/3.00)",
+	"BankApp/5.14.2 (Android 14; Pixel 8)",
+	"BankApp/5.13.0 (Android 13; SM-S911B)",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+}
+
+// origin is the address, device and place a transaction was made from
+type origin struct {
+	ip, device, agent string
+	at                geo.Point
+}
+
+// newOrigin is a device never seen before, at an address in country
+func newOrigin(src *rng.Source, country *geo.Country, agents []string) origin {
+	return origin{
+		ip:     country.IPv4(src).String(),
+		device: fmt.Sprintf("%016x", src.Uint64()),
+		agent:  agents[src.Intn(len(agents))],
+		at:     country.Point(src),
+	}
+}
+
+// usualOrigins gives every account the device and address in its tenant's
+// country its holder banks from. They derive from the account and the
+// root seed only, so an account keeps them from one seed run to the next.
+func usualOrigins(rootSeed int64, accounts []AccountInfo, locales map[int64]*person.Locale) (map[int64]origin, error) {
+	root := rng.New(rootSeed, time.Time{})
+	origins := make(map[int64]origin, len(accounts))
+	for _, a := range accounts {
+		home, err := homeCountry(locales, a.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		origins[a.AccountID] = newOrigin(root.Fork(a.AccountID), home, customerAgents)
+	}
+	return origins, nil
+}
+
+// homeCountry is the country of a tenant
+func homeCountry(locales map[int64]*person.Locale, tenantID int64) (*geo.Country, error) {
+	locale, ok := locales[tenantID]
+	if !ok {
+		return nil, fmt.Errorf("no locale for tenant %d", tenantID)
+	}
+	home, err := geo.Lookup(locale.Country)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %d: %w", tenantID, err)
+	}
+	return home, nil
+}
+
+// fraudTxn is a transaction injected by a fraud scenario, with its ground
+// truth label and the alert raised on it
+type fraudTxn struct {
+	scenario string // the label, a fraud_scenario of weights.yaml or card_testing
+	episode  string // shared by the transactions of one episode
+	from, to AccountInfo
+	txnType  string
+	amount   float64
+	status   string
+	date     time.Time
+	origin   origin
+	card     *cardAuthorization // set instead of the rest for card testing
+
+	score       float64
+	description string
+	detected    time.Time
+	alertStatus string
+	resolved    any // time.Time, or nil while the alert is open
+}
+
+// draft is the fraudTxn as a row of draftTransaction
+func (f *fraudTxn) draft(src *rng.Source) []any {
+	return transactionRow(transactionRef(src, f.from.TenantID), f.from, f.to, f.txnType, f.amount, f.status)
+}
+
+// injection is an injected transaction of a batch
+type injection struct {
+	txn   int // index of the transaction in its batch
+	fraud *fraudTxn
+}
+
+// fraudPlan is the fraud episodes of a seed run, planned before any
+// transaction is drafted so the ledger can date them among the rest
+type fraudPlan struct {
+	txns     []*fraudTxn
+	episodes int
+}
+
+// planFraud draws fraud episodes until they make up ratio of the run's
+// count transactions. Each episode is of a scenario picked by weight,
+// against an account picked uniformly, starting at a date drawn from the
+// window; it is moved back when it would run past the window's end.
+// Victims bank from their origins until the fraud starts.
+func planFraud(src *rng.Source, m *transactionModel, locales map[int64]*person.Locale, origins map[int64]origin, count int, ratio float64) (*fraudPlan, error) {
+	src = src.Fork(fraudStream)
+	countries := geo.Codes()
+	plan := &fraudPlan{}
+	for target := int(math.Round(ratio * float64(count))); len(plan.txns) < target; {
+		victim := m.accounts[src.Intn(len(m.accounts))]
+		home, err := homeCountry(locales, victim.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		e := &episode{
+			src:       src,
+			m:         m,
+			countries: countries,
+			id:        fmt.Sprintf("%016x", src.Uint64()),
+			victim:    victim,
+			home:      home,
+			usual:     origins[victim.AccountID],
+			at:        m.window.Draw(src),
+		}
+		switch scenario := m.cats.fraudScenarios.Pick(src); scenario {
+		case "account_takeover":
+			e.accountTakeover()
+		case "velocity":
+			e.velocity()
+		case "impossible_travel":
+			e.impossibleTravel()
+		default:
+			return nil, fmt.Errorf("unknown fraud scenario %q", scenario)
+		}
+		if len(plan.txns)+len(e.txns) > count {
+			break
+		}
+		e.fit(m.window)
+		e.detect(src.Now)
+		plan.txns = append(plan.txns, e.txns...)
+		plan.episodes++
+	}
+	return plan, nil
+}
+
+// episode builds the transactions of one fraud episode against the
+// victim's account
+type episode struct {
+	src       *rng.Source
+	m         *transactionModel
+	countries []string
+	id        string
+	victim    AccountInfo
+	home      *geo.Country
+	usual     origin    // where the victim banks from
+	at        time.Time // date of the next transaction
+	txns      []*fraudTxn
+}
+
+// accountTakeover: someone with the customer's credentials signs in from
+// a new device abroad and moves large round sums to one or two mule
+// accounts, minutes apart
+func (e *episode) accountTakeover() {
+	country := e.abroad()
+	thief := newOrigin(e.src, country, customerAgents)
+	mules := make([]AccountInfo, 1+e.src.Intn(2))
+	for i := range mules {
+		mules[i] = e.payee()
+	}
+	n := 2 + e.src.Intn(4)
+	for range n {
+		amount := float64(50+e.src.Intn(451)) * 10 // $500 to $5,000
+		e.add("account_takeover", mules[e.src.Intn(len(mules))], "transfer", amount, "completed", thief)
+		e.at = e.at.Add(time.Duration(1+e.src.Intn(20)) * time.Minute)
+	}
+	e.alert(80, 99, fmt.Sprintf("%d transfers to new payees from a new device in %s", n, country.Name))
+}
+
+// velocity: the account makes dozens of ordinary looking transactions
+// from its usual device within the hour
+func (e *episode) velocity() {
+	n := 15 + e.src.Intn(26)
+	span := time.Duration(20+e.src.Intn(41)) * time.Minute
+	offsets := make([]time.Duration, n)
+	for i := range offsets {
+		offsets[i] = time.Duration(e.src.Int63n(int64(span)))
+	}
+	slices.Sort(offsets)
+	start := e.at
+	for _, offset := range offsets {
+		e.at = start.Add(offset)
+		txnType := e.m.cats.transactionTypes.Pick(e.src)
+		e.add("velocity", e.payee(), txnType, e.amount(), e.m.cats.transactionStatus.Pick(e.src, txnType), e.usual)
+	}
+	e.alert(45, 80, fmt.Sprintf("%d transactions within %d minutes", n, e.minutes()))
+}
+
+// impossibleTravel: two payments from the account, the second from
+// further away than anyone could have travelled since the first
+func (e *episode) impossibleTravel() {
+	e.add("impossible_travel", e.payee(), "payment", e.amount(), "completed", e.usual)
+	e.at = e.at.Add(time.Duration(10+e.src.Intn(81)) * time.Minute)
+	far := e.farFrom(e.usual.at)
+	e.add("impossible_travel", e.payee(), "payment", e.amount(), "completed", far)
+	e.alert(60, 90, fmt.Sprintf("Payments %.0f km apart within %d minutes",
+		geo.Distance(e.usual.at, far.at), e.minutes()))
+}
+
+// add appends a transaction from the victim's account at the episode's
+// current date
+func (e *episode) add(scenario string, to AccountInfo, txnType string, amount float64, status string, from origin) {
+	e.txns = append(e.txns, &fraudTxn{
+		scenario: scenario,
+		episode:  e.id,
+		from:     e.victim,
+		to:       to,
+		txnType:  txnType,
+		amount:   amount,
+		status:   status,
+		date:     e.at,
+		origin:   from,
+	})
+}
+
+// alert scores each transaction of the episode between low and high and
+// describes what gave it away
+func (e *episode) alert(low, high float64, description string) {
+	for _, f := range e.txns {
+		f.score = math.Round((low+(high-low)*e.src.Float64())*100) / 100
+		f.description = description
+	}
+}
+
+// fit moves the episode back into window, keeping its spacing, when it
+// runs past the end, and truncates its dates to the window's precision
+func (e *episode) fit(window timeline.Model) {
+	last := e.txns[len(e.txns)-1].date
+	shift := last.Sub(window.End) + time.Second
+	for _, f := range e.txns {
+		if shift > 0 {
+			f.date = f.date.Add(-shift)
+		}
+		if f.date = f.date.Truncate(window.Precision); f.date.Before(window.Start) {
+			f.date = window.Start
+		}
+	}
+}
+
+// detect dates the alerts seconds to minutes after their transactions.
+// Alerts open for alertTriage are under investigation, and those open for
+// alertReview are confirmed as fraud.
+func (e *episode) detect(now time.Time) {
+	for _, f := range e.txns {
+		f.detected = f.date.Add(time.Duration(5+e.src.Intn(600)) * time.Second)
+		if f.detected.After(now) && !f.date.After(now) {
+			f.detected = now
+		}
+		switch age := now.Sub(f.detected); {
+		case age < alertTriage:
+			f.alertStatus = "open"
+		case age < alertReview:
+			f.alertStatus = "investigating"
+		default:
+			f.alertStatus = "confirmed"
+			f.resolved = f.detected.Add(alertTriage + time.Duration(e.src.Int63n(int64(alertReview-alertTriage)))).Truncate(time.Second)
+		}
+	}
+}
+
+// payee picks an account other than the victim's
+func (e *episode) payee() AccountInfo {
+	for {
+		if a := e.m.accounts[e.src.Intn(len(e.m.accounts))]; a.AccountID != e.victim.AccountID {
+			return a
+		}
+	}
+}
+
+// amount draws an amount of an ordinary transaction
+func (e *episode) amount() float64 {
+	return math.Round(e.m.amount.Sample(e.src)*100) / 100
+}
+
+// abroad picks a country other than the victim's
+func (e *episode) abroad() *geo.Country {
+	for {
+		if c, err := geo.Lookup(e.countries[e.src.Intn(len(e.countries))]); err == nil && c != e.home {
+			return c
+		}
+	}
+}
+
+// farFrom is a new device at least travelKm from p, or as far as a few
+// tries get when every country is closer
+func (e *episode) farFrom(p geo.Point) origin {
+	var best origin
+	for range 20 {
+		o := newOrigin(e.src, e.abroad(), customerAgents)
+		d := geo.Distance(p, o.at)
+		if d >= travelKm {
+			return o
+		}
+		if best.ip == "" || d > geo.Distance(p, best.at) {
+			best = o
+		}
+	}
+	return best
+}
+
+// minutes is how long the episode has lasted so far, in whole minutes
+func (e *episode) minutes() int {
+	return int(math.Ceil(e.txns[len(e.txns)-1].date.Sub(e.txns[0].date).Minutes()))
+}
+
+// cardAuthorization is a card_transactions row of a card testing episode
+type cardAuthorization struct {
+	card     cardKey
+	purchase merchant.Purchase
+	authCode any // nil unless approved
+}
+
+// planCardTesting draws card testing episodes until they make up ratio of
+// the run's count card transactions, against cards picked uniformly,
+// starting at a date drawn from window but not before the card was issued
+func planCardTesting(src *rng.Source, cards []cardKey, locales map[int64]*person.Locale, window timeline.Model, count int, ratio float64) (*fraudPlan, error) {
+	src = src.Fork(cardFraudStream)
+	countries := merchant.Codes()
+	plan := &fraudPlan{}
+	for target := int(math.Round(ratio * float64(count))); len(plan.txns) < target; {
+		victim := cards[src.Intn(len(cards))]
+		home, err := homeCountry(locales, victim.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		e := &episode{
+			src:       src,
+			countries: countries,
+			id:        fmt.Sprintf("%016x", src.Uint64()),
+			home:      home,
+			at:        window.Draw(src),
+		}
+		if e.at.Before(victim.Issued) {
+			e.at = victim.Issued
+		}
+		if err := e.cardTesting(victim); err != nil {
+			return nil, err
+		}
+		if len(plan.txns)+len(e.txns) > count {
+			break
+		}
+		e.fit(window)
+		e.detect(src.Now)
+		plan.txns = append(plan.txns, e.txns...)
+		plan.episodes++
+	}
+	return plan, nil
+}
+
+// cardTesting: a script tries a stolen card with payments of a few dollars
+// at a handful of merchants abroad, seconds apart. Most are declined.
+func (e *episode) cardTesting(victim cardKey) error {
+	market, err := merchant.In(e.abroad().Code)
+	if err != nil {
+		return err
+	}
+	merchants := make([]merchant.Merchant, 1+e.src.Intn(3))
+	for i := range merchants {
+		merchants[i] = market.Purchase(e.src).Merchant
+	}
+	n := 8 + e.src.Intn(18)
+	for range n {
+		auth := &cardAuthorization{card: victim, purchase: merchant.Purchase{
+			Merchant: merchants[e.src.Intn(len(merchants))],
+			Amount:   market.Price(0.5 + 4.5*e.src.Float64()),
+			Currency: market.Currency,
+		}}
+		status := "declined"
+		if e.src.Float64() < 0.3 {
+			status = "approved"
+			auth.authCode = fmt.Sprintf("%06d", e.src.Intn(1000000))
+		}
+		e.txns = append(e.txns, &fraudTxn{scenario: "card_testing", episode: e.id, status: status, date: e.at, card: auth})
+		e.at = e.at.Add(time.Duration(5+e.src.Intn(55)) * time.Second)
+	}
+	e.alert(70, 95, fmt.Sprintf("%d payments under $5 at %d merchants in %s within %d minutes",
+		n, len(merchants), market.Country, e.minutes()))
+	return nil
+}
+
+// cardRow is the card_transactions row of a card testing authorization,
+// after card_txn_id
+func (f *fraudTxn) cardRow() []any {
+	a := f.card
+	return []any{a.card.CardID, a.card.TenantID, a.purchase.Merchant.Name, a.purchase.Merchant.Category.MCC,
+		a.purchase.Merchant.Country, a.purchase.Amount, a.purchase.Currency, f.status, a.authCode, f.date, f.date}
+}
+
+// cardAlert is the fraud_alerts row of a card testing authorization
+func (f *fraudTxn) cardAlert(cardTxnID int64) []any {
+	c := f.card.card
+	return []any{c.TenantID, cardTxnID, c.CustomerID, f.scenario, f.score,
+		f.alertStatus, f.description, f.detected, f.resolved, f.detected}
+}
+
+// legitLabel is the fraud_label of the transactions no scenario injected
+const legitLabel = "legit"
+
+// labels builds the transaction_metadata rows of the injected transactions
+// of the batch: their fraud_label, episode and origin, and a fraud_alerts
+// row for each. Transactions without a fraud_label are legit. With
+// labelAll every other transaction is labelled legit too, with the usual
+// origin of its source account, five rows each.
+func (b transactionBatch) labels(ids []int64) (metadata, alerts [][]any) {
+	next := 0 // into b.injected, which is in batch order
+	for i, row := range b.rows {
+		tenantID, date := row[0], row[len(row)-1]
+		label, from := legitLabel, b.origins[row[2].(int64)]
+		if next < len(b.injected) && b.injected[next].txn == i {
+			f := b.injected[next].fraud
+			next++
+			label, from = f.scenario, f.origin
+			metadata = append(metadata, []any{ids[i], tenantID, "fraud_episode", f.episode, date})
+			alerts = append(alerts, []any{f.from.TenantID, ids[i], f.from.CustomerID, f.scenario, f.score,
+				f.alertStatus, f.description, f.detected, f.resolved, f.detected})
+		} else if !b.labelAll {
+			continue
+		}
+		for _, kv := range [][2]string{
+			{"fraud_label", label},
+			{"ip_address", from.ip},
+			{"device_id", from.device},
+			{"user_agent", from.agent},
+			{"location", fmt.Sprintf("%.6f,%.6f", from.at.Lat, from.at.Lon)},
+		} {
+			metadata = append(metadata, []any{ids[i], tenantID, kv[0], kv[1], date})
+		}
+	}
+	return metadata, alerts
+}
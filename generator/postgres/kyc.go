@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"time"
+
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/person"
+	"datagenerator/generator/rng"
+)
+
+// idDocument describes how an identity document looks and how long it is
+// valid
+type idDocument struct {
+	number string // person.Pattern of the document number
+	years  int
+}
+
+var idDocuments = map[string]idDocument{
+	"passport":         {number: "??#######", years: 10},
+	"national_id":      {number: "#########", years: 10},
+	"drivers_license":  {number: "?############", years: 5},
+	"residence_permit": {number: "??########", years: 2},
+}
+
+// fileSizes are the upload sizes in bytes by mime type, log-normal around
+// a typical file
+var fileSizes = map[string]distribution.Distribution{
+	"application/pdf": sizeAround(180 << 10),
+	"image/jpeg":      sizeAround(1200 << 10),
+	"image/png":       sizeAround(600 << 10),
+}
+
+func sizeAround(median float64) distribution.Distribution {
+	return distribution.Clamp(distribution.LogNormal(math.Log(median), 0.6), 10<<10, 20<<20)
+}
+
+var extensions = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+// seedCustomerProfiles gives customers without KYC checks or documents
+// KYCPerCustomer checks and DocumentsPerCustomer uploads
+func seedCustomerProfiles(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, customers []CustomerAccount, config SeedConfig) error {
+	log.Println("Seeding KYC checks and documents...")
+
+	checked, err := customersWith(ctx, db, "customer_kyc")
+	if err != nil {
+		return err
+	}
+	uploaded, err := customersWith(ctx, db, "customer_documents")
+	if err != nil {
+		return err
+	}
+
+	kyc := newRowBatcher(db, config.Copy, "customer_kyc", kycCopyColumns, config.BatchSize)
+	docs := newRowBatcher(db, config.Copy, "customer_documents", documentCopyColumns, config.BatchSize)
+	for _, customer := range customers {
+		if !checked[customer.CustomerID] {
+			for range config.KYCPerCustomer {
+				if err := kyc.add(ctx, kycFields(src, cats, customer, config)); err != nil {
+					return err
+				}
+			}
+		}
+		if !uploaded[customer.CustomerID] {
+			for range config.DocumentsPerCustomer {
+				if err := docs.add(ctx, documentFields(src, cats, customer, config)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := kyc.flush(ctx); err != nil {
+		return err
+	}
+	if err := docs.flush(ctx); err != nil {
+		return err
+	}
+
+	log.Printf("  ✓ Created %d KYC checks\n", kyc.written)
+	log.Printf("  ✓ Created %d customer documents\n", docs.written)
+	return nil
+}
+
+// since draws a time between a customer joining and now, at precision
+func since(src *rng.Source, customer CustomerAccount, precision time.Duration) time.Time {
+	return between(src, customer.Since, src.Now, precision)
+}
+
+// kycFields builds a customer_kyc row for a check started some time after
+// the customer joined. Verified checks complete minutes to days after they
+// start; the document expires some years after it was issued, and may
+// already have expired.
+func kycFields(src *rng.Source, cats *categories, customer CustomerAccount, config SeedConfig) []any {
+	docType := cats.kycDocumentTypes.Pick(src)
+	doc := idDocuments[docType]
+	createdAt := since(src, customer, config.Time.Precision)
+
+	status, updatedAt := "verified", createdAt
+	var verifiedAt any
+	switch r := src.Float64(); {
+	case r < config.KYCPendingRatio:
+		status = "pending"
+	case r < config.KYCPendingRatio+config.KYCFailedRatio:
+		status = "failed"
+	default:
+		delay := 5*time.Minute + time.Duration(src.Int63n(int64(72*time.Hour)))
+		if updatedAt = createdAt.Add(delay).Truncate(config.Time.Precision); updatedAt.After(src.Now) {
+			updatedAt = src.Now
+		}
+		verifiedAt = updatedAt
+	}
+
+	validity := time.Duration(doc.years) * 365 * 24 * time.Hour
+	issued := createdAt.Add(-time.Duration(src.Int63n(int64(validity))))
+	expiry := issued.AddDate(doc.years, 0, 0).Truncate(24 * time.Hour)
+
+	return []any{customer.CustomerID, customer.TenantID, docType, person.Pattern(src, doc.number),
+		status, verifiedAt, expiry, createdAt, updatedAt}
+}
+
+// documentFields builds a customer_documents row for an upload, some time
+// after the customer joined, stored under the customer's prefix in the
+// documents bucket
+func documentFields(src *rng.Source, cats *categories, customer CustomerAccount, config SeedConfig) []any {
+	docType := cats.documentTypes.Pick(src)
+	mime := cats.mimeTypes.Pick(src, docType)
+	createdAt := since(src, customer, config.Time.Precision)
+
+	name := fmt.Sprintf("%s_%s%s", docType, createdAt.Format("2006-01"), extensions[mime])
+	url := "s3://" + path.Join("kyc-documents", fmt.Sprintf("tenant-%d", customer.TenantID),
+		fmt.Sprintf("customer-%d", customer.CustomerID), name)
+	size := int64(fileSizes[mime].Sample(src))
+
+	return []any{customer.CustomerID, customer.TenantID, docType, name, url, size, mime, createdAt}
+}
@@ -0,0 +1,241 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"time"
+
+	"datagenerator/generator/rng"
+	"datagenerator/generator/timeline"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// leg is one side of a posted transaction, waiting for the transaction's id
+type leg struct {
+	txn int   // index of the transaction in its batch
+	row []any // transaction_legs columns after transaction_id
+}
+
+// transactionBatch is a batch of transactions rows and the legs they posted
+type transactionBatch struct {
+	rows     [][]any
+	legs     []leg
+	injected []injection      // rows injected by fraud scenarios
+	origins  map[int64]origin // usual origin of every account, for the metadata of the rest
+	labelAll bool             // label the rest as legit too, see SeedConfig.LabelAll
+	audit    *auditLog        // audits the balance changes
+	now      time.Time        // when the balances change
+}
+
+// ledger posts the completed transactions of a seed run in date order and
+// keeps the running balance of every account, in cents. Generators draft
+// batches in parallel and take turns posting them, batch by batch, so the
+// ledger sees every transaction in the order of its date whatever the
+// number of generators.
+type ledger struct {
+	dates     []time.Time       // transaction dates of the run, in order
+	injected  map[int]*fraudTxn // by transaction of the run
+	balances  map[int64]int64
+	tenants   map[int64]int64 // tenant of every account
+	posted    map[int64]bool  // accounts posted to this run
+	overdraft int64           // how far below zero a debit may take an account
+	turns     []chan struct{} // one per generator; receiving one is the right to post
+}
+
+// newLedger draws transaction dates from window for the count
+// transactions of the run that are not injected, dates the injected ones
+// among them and opens the accounts at their current balance
+func newLedger(ctx context.Context, db *sql.DB, src *rng.Source, accounts []AccountInfo, window timeline.Model, count, generators int, overdraft float64, injected []*fraudTxn) (*ledger, error) {
+	l := &ledger{
+		dates:     make([]time.Time, count),
+		injected:  make(map[int]*fraudTxn, len(injected)),
+		balances:  make(map[int64]int64, len(accounts)),
+		tenants:   make(map[int64]int64, len(accounts)),
+		posted:    map[int64]bool{},
+		overdraft: cents(overdraft),
+		turns:     make([]chan struct{}, generators),
+	}
+	type slot struct {
+		date  time.Time
+		fraud *fraudTxn
+	}
+	slots := make([]slot, 0, count)
+	for range count - len(injected) {
+		slots = append(slots, slot{date: window.Draw(src)})
+	}
+	for _, f := range injected {
+		slots = append(slots, slot{date: f.date, fraud: f})
+	}
+	slices.SortStableFunc(slots, func(a, b slot) int { return a.date.Compare(b.date) })
+	for i, s := range slots {
+		l.dates[i] = s.date
+		if s.fraud != nil {
+			l.injected[i] = s.fraud
+		}
+	}
+	for g := range l.turns {
+		l.turns[g] = make(chan struct{}, 1)
+	}
+	l.turns[0] <- struct{}{}
+
+	ids := make([]int64, len(accounts))
+	for i, a := range accounts {
+		ids[i] = a.AccountID
+		l.tenants[a.AccountID] = a.TenantID
+	}
+	rows, err := db.QueryContext(ctx,
+		"SELECT account_id, COALESCE(current_balance, 0)::float8 FROM account_balances WHERE account_id = ANY($1)", ids)
+	if err != nil {
+		return nil, fmt.Errorf("loading balances: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var balance float64
+		if err := rows.Scan(&id, &balance); err != nil {
+			return nil, err
+		}
+		l.balances[id] = cents(balance)
+	}
+	return l, rows.Err()
+}
+
+// injection is the run's n-th transaction when a fraud scenario injects
+// it, nil otherwise
+func (l *ledger) injection(n int) *fraudTxn {
+	return l.injected[n]
+}
+
+// cents converts an amount to whole cents
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// post waits for generator g's turn, dates the batch of rows of
+// draftTransaction starting at the run's start-th transaction, and posts the
+// completed ones: a debit leg on the source account and a credit leg on the
+// destination, each under its account's tenant, with amounts that sum to
+// zero. A debit the source cannot cover fails the transaction instead.
+func (l *ledger) post(ctx context.Context, g, start int, rows [][]any) ([]leg, error) {
+	select {
+	case <-l.turns[g]:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { l.turns[(g+1)%len(l.turns)] <- struct{}{} }()
+
+	var legs []leg
+	for i, row := range rows {
+		date := l.dates[start+i]
+		rows[i] = append(row, date)
+		if row[7] != "completed" {
+			continue
+		}
+
+		from, to := row[2].(int64), row[3].(int64)
+		amount := cents(row[5].(float64))
+		if l.balances[from]-amount < -l.overdraft {
+			rows[i][7] = "failed" // insufficient funds
+			continue
+		}
+		l.balances[from] -= amount
+		l.balances[to] += amount
+		l.posted[from], l.posted[to] = true, true
+		legs = append(legs,
+			leg{txn: i, row: []any{l.tenants[from], from, "debit", money(-amount), money(l.balances[from]), date}},
+			leg{txn: i, row: []any{l.tenants[to], to, "credit", money(amount), money(l.balances[to]), date}})
+	}
+	return legs, nil
+}
+
+// lockBalances locks the balances of accounts $1 in account order, so
+// batches committing in parallel wait for each other instead of deadlocking
+const lockBalances = `SELECT 1 FROM account_balances WHERE account_id = ANY($1) ORDER BY account_id FOR UPDATE`
+
+// updateBalances adds $2 to the balances of accounts $1, sets their last
+// transaction date to $3 unless it is already later, and returns the
+// columns it changed, before and after
+const updateBalances = `
+	UPDATE account_balances b
+	SET current_balance = COALESCE(b.current_balance, 0) + v.amount,
+		available_balance = COALESCE(b.current_balance, 0) + v.amount - COALESCE(b.hold_balance, 0),
+		last_transaction_date = GREATEST(b.last_transaction_date, v.last), updated_at = $4
+	FROM unnest($1::bigint[], $2::float8[], $3::timestamp[]) AS v(account_id, amount, last),
+		account_balances o -- read before the update
+	WHERE b.account_id = v.account_id AND o.balance_id = b.balance_id
+	RETURNING b.balance_id, b.tenant_id, b.updated_at,
+		jsonb_build_object('available_balance', o.available_balance, 'current_balance', o.current_balance,
+			'last_transaction_date', o.last_transaction_date, 'updated_at', o.updated_at) AS old_values,
+		jsonb_build_object('available_balance', b.available_balance, 'current_balance', b.current_balance,
+			'last_transaction_date', b.last_transaction_date, 'updated_at', b.updated_at) AS new_values`
+
+// postings sums the legs of the batch by account, in account order: the
+// change to each balance and the date of its last posting
+func (b transactionBatch) postings() (ids []int64, amounts []float64, dates []time.Time) {
+	sums := map[int64]int64{}
+	last := map[int64]time.Time{}
+	for _, l := range b.legs {
+		account, date := l.row[1].(int64), l.row[5].(time.Time)
+		sums[account] += cents(l.row[3].(float64))
+		if date.After(last[account]) {
+			last[account] = date
+		}
+	}
+	ids = slices.Sorted(maps.Keys(sums))
+	for _, id := range ids {
+		amounts = append(amounts, money(sums[id]))
+		dates = append(dates, last[id])
+	}
+	return ids, amounts, dates
+}
+
+// balanceUpdate moves the balances of the accounts the batch posted to,
+// audited by the tenants' batch users. The query is empty when the batch
+// posted nothing.
+func (b transactionBatch) balanceUpdate() (ids []int64, query string, args []any) {
+	ids, amounts, dates := b.postings()
+	if len(ids) == 0 {
+		return nil, "", nil
+	}
+	s := b.audit.balancesUpdated()
+	if s.query == "" {
+		s.query = updateBalances
+	}
+	return ids, s.query, append([]any{ids, amounts, dates, b.now}, s.args...)
+}
+
+// copyBalances applies the batch's balance changes in the transaction
+// that loads its legs
+func (b transactionBatch) copyBalances(ctx context.Context, tx pgx.Tx) error {
+	ids, query, args := b.balanceUpdate()
+	if query == "" {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, lockBalances, ids); err != nil {
+		return fmt.Errorf("locking balances: %w", err)
+	}
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("updating balances: %w", err)
+	}
+	return nil
+}
+
+// insertBalances is copyBalances for database/sql
+func (b transactionBatch) insertBalances(ctx context.Context, tx *sql.Tx) error {
+	ids, query, args := b.balanceUpdate()
+	if query == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, lockBalances, ids); err != nil {
+		return fmt.Errorf("locking balances: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("updating balances: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"datagenerator/generator/distribution"
+	"datagenerator/generator/rng"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// loanProduct is the shape of one loan type: principal, annual rate range
+// and the tenures it is offered with, in months
+type loanProduct struct {
+	principal distribution.Distribution
+	minRate   float64
+	maxRate   float64
+	tenures   []int
+}
+
+var loanProducts = map[string]loanProduct{
+	"personal": {principalAround(8_000, 1_000, 50_000), 0.08, 0.20, []int{12, 24, 36, 48, 60}},
+	"auto":     {principalAround(25_000, 5_000, 90_000), 0.04, 0.10, []int{36, 48, 60, 72}},
+	"mortgage": {principalAround(280_000, 50_000, 2_000_000), 0.03, 0.07, []int{180, 240, 360}},
+	"student":  {principalAround(20_000, 2_000, 120_000), 0.04, 0.08, []int{60, 120}},
+	"business": {principalAround(60_000, 10_000, 1_000_000), 0.06, 0.14, []int{12, 24, 36, 60}},
+}
+
+func principalAround(median, min, max float64) distribution.Distribution {
+	return distribution.Clamp(distribution.LogNormal(math.Log(median), 0.7), min, max)
+}
+
+const (
+	// latePenaltyRate is the late fee as a share of the installment
+	latePenaltyRate = 0.05
+	// minLatePenalty is the smallest late fee, in cents
+	minLatePenalty = 1500
+)
+
+// loan is a generated loan with its schedule and repayments, waiting for an
+// id. Amounts are in cents.
+type loan struct {
+	account   AccountInfo
+	loanType  string
+	principal int64
+	rate      float64
+	tenure    int
+	status    string
+	disbursed time.Time
+	changed   time.Time // when the loan was closed or fell behind, zero while active
+
+	// loan_schedules and loan_repayments rows after loan_id and tenant_id
+	schedule   [][]any
+	repayments [][]any
+}
+
+// installment is one level monthly payment, split into principal and
+// interest, in cents
+type installment struct {
+	principal int64
+	interest  int64
+}
+
+// amortize splits principal into tenure level monthly payments at the
+// annual rate. Interest is charged on the outstanding balance and the last
+// payment clears whatever rounding left.
+func amortize(principal int64, rate float64, tenure int) []installment {
+	r := rate / 12
+	payment := float64(principal) / float64(tenure)
+	if r > 0 {
+		payment = float64(principal) * r / (1 - math.Pow(1+r, -float64(tenure)))
+	}
+	level := int64(math.Round(payment))
+
+	balance := principal
+	schedule := make([]installment, tenure)
+	for i := range schedule {
+		interest := int64(math.Round(float64(balance) * r))
+		part := level - interest
+		if i == tenure-1 || part > balance {
+			part = balance
+		}
+		balance -= part
+		schedule[i] = installment{principal: part, interest: interest}
+	}
+	return schedule
+}
+
+// addMonths moves day n months on, clamping to the end of a shorter month
+// where AddDate would spill into the next: Jan 31 plus one month is Feb 28.
+func addMonths(day time.Time, n int) time.Time {
+	first := time.Date(day.Year(), day.Month()+time.Month(n), 1,
+		day.Hour(), day.Minute(), day.Second(), day.Nanosecond(), day.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day.Day(), last)-1)
+}
+
+// money converts cents to the decimal the amount columns hold
+func money(cents int64) float64 {
+	return float64(cents) / 100
+}
+
+// newLoan originates a loan on account some day after the account opened
+// and pays the installments due by now: on time, late with a penalty, or in
+// part. An installment still unpaid when it falls due is overdue.
+func newLoan(src *rng.Source, cats *categories, account AccountInfo, config SeedConfig) *loan {
+	loanType := cats.loanTypes.Pick(src)
+	product := loanProducts[loanType]
+	l := &loan{
+		account:  account,
+		loanType: loanType,
+		// Whole hundreds, in cents
+		principal: int64(math.Round(product.principal.Sample(src)/100)) * 100 * 100,
+		rate:      math.Round((product.minRate+(product.maxRate-product.minRate)*src.Float64())*400) / 400,
+		tenure:    product.tenures[src.Intn(len(product.tenures))],
+	}
+	now := src.Now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	l.disbursed = today
+	if days := int(today.Sub(firstDay(account.Opened)).Hours() / 24); days > 0 {
+		l.disbursed = today.AddDate(0, 0, -src.Intn(days+1))
+	}
+
+	paid := 0
+	for i, due := range amortize(l.principal, l.rate, l.tenure) {
+		dueDate := addMonths(l.disbursed, i+1)
+		total := due.principal + due.interest
+		status := "pending"
+
+		if !dueDate.After(today) {
+			switch r := src.Float64(); {
+			case r < config.LatePaymentRatio:
+				payDate := dueDate.AddDate(0, 0, 1+src.Intn(45))
+				if payDate.After(today) {
+					status = "overdue"
+					l.fallBehind(dueDate)
+					break
+				}
+				penalty := max(int64(math.Round(float64(total)*latePenaltyRate)), minLatePenalty)
+				l.repay(payDate, due.principal, due.interest, penalty, "late")
+				status = "paid"
+			case r < config.LatePaymentRatio+config.PartialPaymentRatio:
+				// Partial payments cover interest first
+				amount := int64(math.Round(float64(total) * (0.3 + 0.6*src.Float64())))
+				interest := min(due.interest, amount)
+				l.repay(dueDate.AddDate(0, 0, -src.Intn(4)), amount-interest, interest, 0, "partial")
+				status = "partially_paid"
+				l.fallBehind(dueDate)
+			default:
+				l.repay(dueDate.AddDate(0, 0, -src.Intn(4)), due.principal, due.interest, 0, "paid")
+				status = "paid"
+			}
+		}
+		if status == "paid" {
+			paid++
+		}
+		l.schedule = append(l.schedule, []any{i + 1, dueDate, money(due.principal), money(due.interest),
+			money(total), status, l.disbursed})
+	}
+
+	switch {
+	case paid == l.tenure:
+		l.status = "closed"
+		for _, r := range l.repayments {
+			if day := r[0].(time.Time); day.After(l.changed) {
+				l.changed = day
+			}
+		}
+	case !l.changed.IsZero():
+		l.status = "delinquent"
+	default:
+		l.status = "active"
+	}
+	return l
+}
+
+// fallBehind marks the loan delinquent from day, unless it already was
+func (l *loan) fallBehind(day time.Time) {
+	if l.changed.IsZero() {
+		l.changed = day
+	}
+}
+
+// updated is when the loan row last changed
+func (l *loan) updated() time.Time {
+	if l.changed.IsZero() {
+		return l.disbursed
+	}
+	return l.changed
+}
+
+// repay records a repayment made on day
+func (l *loan) repay(day time.Time, principal, interest, penalty int64, status string) {
+	l.repayments = append(l.repayments, []any{day, money(principal), money(interest), money(penalty),
+		money(principal + interest + penalty), status, day})
+}
+
+// rows builds the loans, loan_schedules and loan_repayments rows once the
+// loan has an id
+func (l *loan) rows(id int64) (loanRow []any, schedule, repayments [][]any) {
+	a := l.account
+	loanRow = []any{id, a.TenantID, a.CustomerID, a.AccountID, fmt.Sprintf("LN%010d", id), l.loanType,
+		money(l.principal), l.rate, l.tenure, l.status, l.disbursed, addMonths(l.disbursed, l.tenure),
+		l.disbursed, l.updated()}
+	for _, row := range l.schedule {
+		schedule = append(schedule, append([]any{id, a.TenantID}, row...))
+	}
+	for _, row := range l.repayments {
+		repayments = append(repayments, append([]any{id, a.TenantID}, row...))
+	}
+	return loanRow, schedule, repayments
+}
+
+// seedLoans gives LoanRatio of the customers without loans one on their
+// first account, with its amortization schedule and repayment history
+func seedLoans(ctx context.Context, db *sql.DB, src *rng.Source, cats *categories, audit *auditLog, accounts []AccountInfo, config SeedConfig) error {
+	existing, err := customersWith(ctx, db, "loans")
+	if err != nil {
+		return err
+	}
+
+	var pending []*loan
+	installments := 0
+	var loans, schedules, repayments int
+	flush := func() error {
+		s, r, err := writeLoans(ctx, db, config.Copy, audit, pending)
+		if err != nil {
+			return err
+		}
+		loans, schedules, repayments = loans+len(pending), schedules+s, repayments+r
+		pending, installments = pending[:0], 0
+		return nil
+	}
+
+	for _, account := range accounts {
+		if existing[account.CustomerID] {
+			continue
+		}
+		// Only the first account of each customer is considered
+		existing[account.CustomerID] = true
+		if src.Float64() >= config.LoanRatio {
+			continue
+		}
+
+		l := newLoan(src, cats, account, config)
+		pending = append(pending, l)
+		if installments += len(l.schedule); installments >= config.BatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(pending) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("  ✓ Created %d loans with %d installments and %d repayments\n", loans, schedules, repayments)
+	return nil
+}
+
+// writeLoans reserves ids for loans and writes them with their schedules,
+// repayments and audit rows in one transaction
+func writeLoans(ctx context.Context, db *sql.DB, useCopy bool, audit *auditLog, loans []*loan) (schedules, repayments int, err error) {
+	first, last := loans[0].disbursed, loans[0].updated()
+	for _, l := range loans {
+		if l.disbursed.Before(first) {
+			first = l.disbursed
+		}
+		if l.updated().After(last) {
+			last = l.updated()
+		}
+	}
+	if err := audit.cover(ctx, first, last); err != nil {
+		return 0, 0, err
+	}
+
+	tenantIDs := make([]int64, len(loans))
+	build := func(ids []int64) (loanRows, scheduleRows, repaymentRows [][]any) {
+		for i, l := range loans {
+			row, schedule, paid := l.rows(ids[i])
+			loanRows = append(loanRows, row)
+			scheduleRows = append(scheduleRows, schedule...)
+			repaymentRows = append(repaymentRows, paid...)
+			tenantIDs[i] = l.account.TenantID
+		}
+		return loanRows, scheduleRows, repaymentRows
+	}
+
+	if useCopy {
+		err = withCopyTx(ctx, db, func(tx pgx.Tx) error {
+			ids, err := reserveIDs(ctx, tx, "loans", "loan_id", len(loans))
+			if err != nil {
+				return err
+			}
+			loanRows, scheduleRows, repaymentRows := build(ids)
+			schedules, repayments = len(scheduleRows), len(repaymentRows)
+			if err := copyRows(ctx, tx, "loans", loanCopyColumns, loanRows); err != nil {
+				return err
+			}
+			if err := copyRows(ctx, tx, "loan_schedules", loanScheduleCopyColumns, scheduleRows); err != nil {
+				return err
+			}
+			if err := copyRows(ctx, tx, "loan_repayments", loanRepaymentCopyColumns, repaymentRows); err != nil {
+				return err
+			}
+			return audit.loansCreated(ids, tenantIDs).copyTx(ctx, tx)
+		})
+		return schedules, repayments, err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	ids, err := nextIDs(ctx, tx, "loans", "loan_id", len(loans))
+	if err != nil {
+		return 0, 0, err
+	}
+	loanRows, scheduleRows, repaymentRows := build(ids)
+	if err := insertRows(ctx, tx, "loans", loanCopyColumns, loanRows); err != nil {
+		return 0, 0, err
+	}
+	if err := insertRows(ctx, tx, "loan_schedules", loanScheduleCopyColumns, scheduleRows); err != nil {
+		return 0, 0, err
+	}
+	if err := insertRows(ctx, tx, "loan_repayments", loanRepaymentCopyColumns, repaymentRows); err != nil {
+		return 0, 0, err
+	}
+	if err := audit.loansCreated(ids, tenantIDs).sqlTx(ctx, tx); err != nil {
+		return 0, 0, err
+	}
+	return len(scheduleRows), len(repaymentRows), tx.Commit()
+}
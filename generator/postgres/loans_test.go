@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAmortize(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal int64
+		rate      float64
+		tenure    int
+	}{
+		{"personal", 1_500_000, 0.0975, 36},
+		{"mortgage", 35_000_000, 0.0425, 360},
+		{"interest free", 1_000_000, 0, 7},
+		{"single payment", 50_000, 0.12, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := amortize(tt.principal, tt.rate, tt.tenure)
+			if len(schedule) != tt.tenure {
+				t.Fatalf("got %d installments, want %d", len(schedule), tt.tenure)
+			}
+			balance := tt.principal
+			for i, due := range schedule {
+				if due.principal < 0 || due.interest < 0 {
+					t.Fatalf("installment %d = %+v, want no negative parts", i, due)
+				}
+				balance -= due.principal
+			}
+			if balance != 0 {
+				t.Errorf("principals leave a balance of %d, want 0", balance)
+			}
+			if tt.rate == 0 && schedule[0].interest != 0 {
+				t.Errorf("interest = %d at rate 0, want 0", schedule[0].interest)
+			}
+		})
+	}
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		day  string
+		n    int
+		want string
+	}{
+		{"2025-01-15", 1, "2025-02-15"},
+		{"2025-01-31", 1, "2025-02-28"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2025-01-31", 2, "2025-03-31"},
+		{"2025-03-30", 1, "2025-04-30"},
+		{"2025-08-31", 6, "2026-02-28"},
+		{"2025-12-31", 12, "2026-12-31"},
+	}
+	for _, tt := range tests {
+		day, _ := time.Parse(time.DateOnly, tt.day)
+		if got := addMonths(day, tt.n).Format(time.DateOnly); got != tt.want {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.day, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,555 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"time"
+
+	"datagenerator/config"
+	"datagenerator/generator/person"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/timeline"
+	"datagenerator/generator/weighted"
+	"datagenerator/generator/workload"
+)
+
+// MutateConfig controls a change-data-capture workload against an already
+// seeded banking schema
+type MutateConfig struct {
+	Operations  int            // Total inserts, updates and deletes to apply
+	Mix         workload.Mix   // Share of each operation
+	BatchSize   int            // Operations per transaction
+	Seed        int64          // Seed for every random choice
+	Now         time.Time      // Frozen clock used for updated_at
+	Time        timeline.Model // When inserted transactions happen
+	AccountSkew float64        // Zipf exponent for picking accounts, 0 for uniform
+	Overdraft   float64        // How far below zero a posting may take an account, 0 for no overdrafts
+	Weights     string         // Weights file for categorical fields, empty for the built-in weights.yaml
+	Audit       bool           // Write audit_logs rows for updates
+}
+
+// DefaultMutateConfig returns the configuration used for a standard run
+func DefaultMutateConfig() MutateConfig {
+	now := time.Now().UTC()
+	return MutateConfig{
+		Operations:  100_000,
+		Mix:         workload.Mix{Insert: 70, Update: 25, Delete: 5},
+		BatchSize:   500,
+		Now:         now,
+		Time:        DefaultTimeModel(now),
+		AccountSkew: 1.1,
+	}
+}
+
+// Validate reports configuration values the workload cannot run with
+func (c MutateConfig) Validate() error {
+	if c.Operations <= 0 {
+		return fmt.Errorf("operations must be positive, got %d", c.Operations)
+	}
+	if c.BatchSize <= 0 {
+		return fmt.Errorf("batch size must be positive, got %d", c.BatchSize)
+	}
+	if err := c.Time.Validate(); err != nil {
+		return fmt.Errorf("time window: %w", err)
+	}
+	if c.AccountSkew != 0 && c.AccountSkew <= 1 {
+		return fmt.Errorf("account skew must be 0 or above 1, got %v", c.AccountSkew)
+	}
+	if c.Overdraft < 0 {
+		return fmt.Errorf("overdraft must not be negative, got %v", c.Overdraft)
+	}
+	return nil
+}
+
+// mutationPoolSize caps how many existing rows of each kind are loaded as
+// update and delete targets
+const mutationPoolSize = 100000
+
+// transactionKey is the primary key of a partitioned transactions row
+type transactionKey struct {
+	TransactionID int64
+	TenantID      int64
+	Date          time.Time
+}
+
+// The changes an update operation can make. Balances only change by
+// posting transactions: settling a pending one, or a transfer made now.
+const (
+	updateTransactionStatus = iota
+	postTransfer
+	updateCustomer
+	closeAccount
+)
+
+// updateWeights spreads updates over the change kinds above
+var updateWeights = []float64{40, 40, 15, 5}
+
+// customerKey is a customer and the locale of their tenant's country
+type customerKey struct {
+	CustomerID int64
+	TenantID   int64
+	Locale     *person.Locale
+}
+
+// mutator holds the key pools a workload draws from
+type mutator struct {
+	src          *rng.Source
+	audit        *auditLog
+	updates      *weighted.Alias  // picks the change kind of an update
+	model        transactionModel // active accounts, for new transactions and closures
+	overdraft    int64            // in cents, see MutateConfig.Overdraft
+	customers    workload.KeyPool[customerKey]
+	transactions workload.KeyPool[transactionKey] // delete targets, of which remove skips completed ones
+	pending      workload.KeyPool[transactionKey] // transactions still to settle
+	closing      map[int64]bool                   // accounts closed by the open transaction
+}
+
+func PerformMutations(conn config.Connection, cfg MutateConfig) {
+	db, err := sql.Open("pgx", conn.PostgresDSN())
+	if err != nil {
+		log.Printf("error: %v", err.Error())
+		return
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+
+	log.Printf("Starting mutation workload: %d operations, mix %s\n", cfg.Operations, &cfg.Mix)
+
+	if err := mutateData(ctx, db, cfg); err != nil {
+		log.Fatalf("Failed to apply mutations: %v", err)
+	}
+
+	log.Println("Mutation workload completed successfully!")
+}
+
+func mutateData(ctx context.Context, db *sql.DB, cfg MutateConfig) error {
+	startTime := time.Now()
+	cats, err := loadCategories(cfg.Weights)
+	if err != nil {
+		return err
+	}
+	updates, err := weighted.NewAlias(updateWeights)
+	if err != nil {
+		return err
+	}
+	m := &mutator{src: rng.New(cfg.Seed, cfg.Now), updates: updates, overdraft: cents(cfg.Overdraft),
+		closing: map[int64]bool{}}
+	accounts, err := m.loadPools(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to load key pools: %w", err)
+	}
+	log.Printf("✓ Key pools ready: %d accounts, %d customers, %d transactions, %d pending\n",
+		len(accounts), m.customers.Len(), m.transactions.Len(), m.pending.Len())
+	if len(accounts) < 2 {
+		return fmt.Errorf("need at least 2 active accounts, run seed first")
+	}
+	if m.model, err = newTransactionModel(accounts, cats, cfg.AccountSkew, cfg.Time); err != nil {
+		return err
+	}
+	if cfg.Audit {
+		if m.audit, err = allTenantsAudit(ctx, db, m.src); err != nil {
+			return err
+		}
+	}
+
+	// Transfers are made now, which may be past the window
+	last := cfg.Time.End
+	if cfg.Now.After(last) {
+		last = cfg.Now
+	}
+	if err := ensurePartitions(ctx, db, "transactions", cfg.Time.Start, last); err != nil {
+		return err
+	}
+
+	// New transactions number their refs from a block of their own
+	m.src.IDs = rng.IDRange{Next: m.src.Int63n(1e13 - int64(cfg.Operations))}
+
+	var counts workload.Counts
+	for done := 0; done < cfg.Operations; {
+		n := min(cfg.BatchSize, cfg.Operations-done)
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for range n {
+			op, err := m.apply(ctx, tx, cfg.Mix.Pick(m.src))
+			if err != nil {
+				tx.Rollback()
+				return fmt.Errorf("%s failed: %w", op, err)
+			}
+			counts[op]++
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		m.closed()
+
+		previous := done
+		done += n
+		if done/10000 != previous/10000 || done == cfg.Operations {
+			log.Printf("  Progress: %d/%d (%s)\n", done, cfg.Operations, counts)
+		}
+	}
+
+	elapsed := time.Since(startTime)
+	log.Printf("✓ Applied %s\n", counts)
+	log.Printf("Total time: %s\n", elapsed)
+	log.Printf("Throughput: %.0f operations/second\n", float64(cfg.Operations)/elapsed.Seconds())
+	return nil
+}
+
+// loadPools reads the newest customers, transactions and pending
+// transactions into the key pools and returns the newest active accounts
+func (m *mutator) loadPools(ctx context.Context, db *sql.DB) ([]AccountInfo, error) {
+	var accounts []AccountInfo
+	rows, err := db.QueryContext(ctx, `
+		SELECT a.account_id, a.tenant_id, ah.customer_id
+		FROM accounts a
+		JOIN account_holders ah ON a.account_id = ah.account_id AND ah.holder_type = 'primary'
+		WHERE a.status = 'active'
+		ORDER BY a.account_id DESC
+		LIMIT $1
+	`, mutationPoolSize)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var a AccountInfo
+		if err := rows.Scan(&a.AccountID, &a.TenantID, &a.CustomerID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	rows.Close()
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT c.customer_id, c.tenant_id, t.country_code
+		FROM customers c
+		JOIN tenants t ON c.tenant_id = t.tenant_id
+		WHERE c.status = 'active'
+		ORDER BY c.customer_id DESC
+		LIMIT $1
+	`, mutationPoolSize)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var k customerKey
+		var country string
+		if err := rows.Scan(&k.CustomerID, &k.TenantID, &country); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if k.Locale, err = person.For(country); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("customer %d: %w", k.CustomerID, err)
+		}
+		m.customers.Add(k)
+	}
+	rows.Close()
+
+	for _, pool := range []struct {
+		keys  *workload.KeyPool[transactionKey]
+		where string
+	}{{&m.transactions, "status <> 'completed'"}, {&m.pending, "status = 'pending'"}} {
+		if err := loadTransactionKeys(ctx, db, pool.keys, pool.where); err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+// loadTransactionKeys adds the newest transactions matching where to pool
+func loadTransactionKeys(ctx context.Context, db *sql.DB, pool *workload.KeyPool[transactionKey], where string) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT transaction_id, tenant_id, transaction_date
+		FROM transactions
+		WHERE `+where+`
+		ORDER BY transaction_id DESC
+		LIMIT $1
+	`, mutationPoolSize)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k transactionKey
+		if err := rows.Scan(&k.TransactionID, &k.TenantID, &k.Date); err != nil {
+			return err
+		}
+		pool.Add(k)
+	}
+	return rows.Err()
+}
+
+// apply runs one operation and returns the operation actually applied:
+// updates and deletes fall back to an insert when they find nothing to change
+func (m *mutator) apply(ctx context.Context, tx *sql.Tx, op workload.Op) (workload.Op, error) {
+	switch op {
+	case workload.Update:
+		applied, err := m.update(ctx, tx)
+		if applied || err != nil {
+			return workload.Update, err
+		}
+	case workload.Delete:
+		if key, ok := m.transactions.Take(m.src); ok {
+			removed, err := m.remove(ctx, tx, key)
+			if removed || err != nil {
+				return workload.Delete, err
+			}
+		}
+	}
+	return workload.Insert, m.insert(ctx, tx)
+}
+
+// remove deletes a transaction that never posted, with its metadata and
+// alerts, which have no foreign keys to cascade from it. Completed
+// transactions stay: their legs are part of every later balance_after of
+// their accounts. It reports false if the transaction is gone or completed.
+func (m *mutator) remove(ctx context.Context, tx *sql.Tx, key transactionKey) (bool, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, `
+		SELECT status FROM transactions
+		WHERE transaction_id = $1 AND tenant_id = $2 AND transaction_date = $3
+		FOR UPDATE
+	`, key.TransactionID, key.TenantID, key.Date).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if status == "completed" {
+		return false, nil
+	}
+
+	for _, table := range []string{"transaction_metadata", "fraud_alerts"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE transaction_id = $1", key.TransactionID); err != nil {
+			return false, err
+		}
+	}
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM transactions WHERE transaction_id = $1 AND tenant_id = $2 AND transaction_date = $3",
+		key.TransactionID, key.TenantID, key.Date)
+	return err == nil, err
+}
+
+// insert adds a pending transaction and pools its key for later changes
+func (m *mutator) insert(ctx context.Context, tx *sql.Tx) error {
+	fields := transactionFields(m.src, &m.model)
+	fields[7] = "pending" // status; later updates settle it
+	key, err := m.add(ctx, tx, fields)
+	if err != nil {
+		return err
+	}
+	m.pending.Add(key)
+	return nil
+}
+
+// add inserts the pending transaction of transactionFields fields and pools
+// its key
+func (m *mutator) add(ctx context.Context, tx *sql.Tx, fields []any) (transactionKey, error) {
+	var key transactionKey
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO transactions
+		(tenant_id, transaction_ref, from_account_id, to_account_id, transaction_type,
+		 amount, currency_code, status, description, transaction_date, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
+		RETURNING transaction_id, tenant_id, transaction_date
+	`, append(fields, m.src.Now)...).Scan(&key.TransactionID, &key.TenantID, &key.Date)
+	if err != nil {
+		return key, err
+	}
+	m.transactions.Add(key)
+	return key, nil
+}
+
+// transfer adds a pending transfer between two accounts, made now
+func (m *mutator) transfer(ctx context.Context, tx *sql.Tx) (transactionKey, error) {
+	from := m.model.pickAccount(m.src)
+	to := m.model.pickAccount(m.src)
+	for to.AccountID == from.AccountID {
+		to = m.model.pickAccount(m.src)
+	}
+	amount := math.Round(m.model.amount.Sample(m.src)*100) / 100
+	row := transactionRow(transactionRef(m.src, from.TenantID), from, to, "transfer", amount, "pending")
+	return m.add(ctx, tx, append(row, m.src.Now))
+}
+
+// settle moves a pending transaction to status, as the batch user of its
+// tenant. Completing it posts its legs, or fails it when either account
+// has been closed or the source cannot cover it. It reports false if the
+// transaction is gone or no longer pending.
+func (m *mutator) settle(ctx context.Context, tx *sql.Tx, key transactionKey, status string) (bool, error) {
+	var from, to AccountInfo
+	var amount float64
+	var current string
+	var closed bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT t.from_account_id, f.tenant_id, t.to_account_id, d.tenant_id, t.amount::float8, t.status,
+			f.status = 'closed' OR d.status = 'closed'
+		FROM transactions t
+		JOIN accounts f ON f.account_id = t.from_account_id
+		JOIN accounts d ON d.account_id = t.to_account_id
+		WHERE t.transaction_id = $1 AND t.tenant_id = $2 AND t.transaction_date = $3
+		FOR UPDATE OF t
+	`, key.TransactionID, key.TenantID, key.Date).Scan(&from.AccountID, &from.TenantID, &to.AccountID, &to.TenantID, &amount, &current, &closed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if current != "pending" {
+		return false, nil
+	}
+	if status == "completed" && closed {
+		status = "failed"
+	}
+	if status == "completed" {
+		posted, err := m.post(ctx, tx, key.TransactionID, from, to, amount)
+		if err != nil {
+			return false, err
+		}
+		if !posted {
+			status = "failed" // insufficient funds
+		}
+	}
+	return true, m.audit.updated(ctx, tx, m.audit.batchUser(key.TenantID), "transactions",
+		rowKey{
+			columns: []string{"transaction_id", "tenant_id", "transaction_date"},
+			values:  []any{key.TransactionID, key.TenantID, key.Date},
+		}, "status_change", func() error {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE transactions SET status = $1, updated_at = $2
+				WHERE transaction_id = $3 AND tenant_id = $4 AND transaction_date = $5
+			`, status, m.src.Now, key.TransactionID, key.TenantID, key.Date)
+			return err
+		})
+}
+
+// post moves amount between the accounts now for transaction id, with a
+// debit and a credit leg, unless the debit would take the source below the
+// overdraft. It reports whether it posted.
+func (m *mutator) post(ctx context.Context, tx *sql.Tx, id int64, from, to AccountInfo, amount float64) (bool, error) {
+	var balance float64
+	err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(current_balance, 0)::float8 FROM account_balances WHERE account_id = $1 FOR UPDATE",
+		from.AccountID).Scan(&balance)
+	if err != nil {
+		return false, fmt.Errorf("balance of account %d: %w", from.AccountID, err)
+	}
+	if cents(balance)-cents(amount) < -m.overdraft {
+		return false, nil
+	}
+	fromAfter, err := m.credit(ctx, tx, from, -amount)
+	if err != nil {
+		return false, err
+	}
+	toAfter, err := m.credit(ctx, tx, to, amount)
+	if err != nil {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO transaction_legs (transaction_id, tenant_id, account_id, leg_type, amount, balance_after, created_at)
+		VALUES ($1, $2, $3, 'debit', $4, $5, $6), ($1, $7, $8, 'credit', $9, $10, $6)
+	`, id, from.TenantID, from.AccountID, -amount, fromAfter, m.src.Now, to.TenantID, to.AccountID, amount, toAfter)
+	return err == nil, err
+}
+
+// credit adds amount, negative for a debit, to an account's balance now,
+// as the batch user of its tenant, and returns the new balance
+func (m *mutator) credit(ctx context.Context, tx *sql.Tx, account AccountInfo, amount float64) (float64, error) {
+	var balance float64
+	err := m.audit.updated(ctx, tx, m.audit.batchUser(account.TenantID), "account_balances",
+		byID("account_id", account.AccountID), "update", func() error {
+			return tx.QueryRowContext(ctx, `
+				UPDATE account_balances
+				SET available_balance = available_balance + $1, current_balance = current_balance + $1,
+					last_transaction_date = GREATEST(last_transaction_date, $2), updated_at = $2
+				WHERE account_id = $3
+				RETURNING current_balance::float8
+			`, amount, m.src.Now, account.AccountID).Scan(&balance)
+		})
+	if err != nil {
+		return 0, fmt.Errorf("balance of account %d: %w", account.AccountID, err)
+	}
+	return balance, nil
+}
+
+// closed takes the accounts closed by a committed transaction out of the
+// model, keeping the order of the rest so Zipf ranks stay with their
+// accounts
+func (m *mutator) closed() {
+	if len(m.closing) == 0 {
+		return
+	}
+	m.model.accounts = slices.DeleteFunc(m.model.accounts, func(a AccountInfo) bool {
+		return m.closing[a.AccountID]
+	})
+	clear(m.closing)
+}
+
+// update applies one change picked by updateWeights. It reports false if
+// the chosen change had nothing to update.
+func (m *mutator) update(ctx context.Context, tx *sql.Tx) (bool, error) {
+	now := m.src.Now
+	switch m.updates.Pick(m.src) {
+	case updateTransactionStatus:
+		key, ok := m.pending.Take(m.src)
+		if !ok {
+			return false, nil
+		}
+		return m.settle(ctx, tx, key, m.model.cats.settledStatus.Pick(m.src))
+
+	case postTransfer:
+		key, err := m.transfer(ctx, tx)
+		if err != nil {
+			return false, err
+		}
+		return m.settle(ctx, tx, key, "completed")
+
+	case updateCustomer:
+		key, ok := m.customers.Pick(m.src)
+		if !ok {
+			return false, nil
+		}
+		// The customer moves to another mail provider, keeping the unique
+		// local part, and takes a new phone number
+		domain, phone := key.Locale.EmailDomain(m.src), key.Locale.Phone(m.src)
+		return true, m.audit.updated(ctx, tx, m.audit.staffUser(key.TenantID), "customers",
+			byID("customer_id", key.CustomerID), "update", func() error {
+				_, err := tx.ExecContext(ctx, `
+					UPDATE customers SET email = split_part(email, '@', 1) || '@' || $1, phone = $2, updated_at = $3
+					WHERE customer_id = $4
+				`, domain, phone, now, key.CustomerID)
+				return err
+			})
+
+	default: // closeAccount
+		// Keep two accounts open so new transactions still have a counterparty
+		accounts := m.model.accounts
+		if len(accounts)-len(m.closing) <= 2 {
+			return false, nil
+		}
+		account := accounts[m.src.Intn(len(accounts))]
+		if m.closing[account.AccountID] {
+			return false, nil
+		}
+		m.closing[account.AccountID] = true
+		return true, m.audit.updated(ctx, tx, m.audit.staffUser(account.TenantID), "accounts",
+			byID("account_id", account.AccountID), "status_change", func() error {
+				_, err := tx.ExecContext(ctx, `
+					UPDATE accounts SET status = 'closed', closed_date = $1, updated_at = $2 WHERE account_id = $3
+				`, now.Truncate(24*time.Hour), now, account.AccountID)
+				return err
+			})
+	}
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// postgresCopySink streams each batch with COPY FROM STDIN over a single
+// pgx connection
+type postgresCopySink struct {
+	dsn     string
+	table   *dataset.Table
+	columns []string
+	conn    *pgx.Conn
+}
+
+// NewPostgresCopySink writes table rows to Postgres using COPY
+func NewPostgresCopySink(conn config.Connection, table *dataset.Table) Sink {
+	return &postgresCopySink{dsn: conn.PostgresDSN(), table: table}
+}
+
+func (s *postgresCopySink) Name() string { return "Postgres (COPY)" }
+
+func (s *postgresCopySink) Open(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, s.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	s.conn = conn
+
+	for _, c := range s.table.Generated() {
+		s.columns = append(s.columns, c.Name)
+	}
+	return nil
+}
+
+func (s *postgresCopySink) EnsureSchema(ctx context.Context) error {
+	for _, stmt := range dataset.Postgres.CreateTable(s.table) {
+		if _, err := s.conn.Exec(ctx, stmt.SQL); err != nil {
+			return fmt.Errorf("failed creating %s %s: %w", stmt.Object, stmt.Name, err)
+		}
+	}
+	return nil
+}
+
+func (s *postgresCopySink) EnsurePartitions(ctx context.Context, from, to time.Time) error {
+	rows, err := s.conn.Query(ctx, dataset.Postgres.ListPartitions(s.table))
+	if err != nil {
+		return fmt.Errorf("failed listing partitions: %w", err)
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed listing partitions: %w", err)
+	}
+
+	for _, stmt := range dataset.Postgres.AddPartitions(s.table, existing, from, to) {
+		if _, err := s.conn.Exec(ctx, stmt.SQL); err != nil {
+			return fmt.Errorf("failed adding partition %s: %w", stmt.Name, err)
+		}
+		fmt.Printf("Partition %s added to %s\n", stmt.Name, s.table.Name)
+	}
+	return nil
+}
+
+func (s *postgresCopySink) WriteBatch(ctx context.Context, rows []dataset.Row) error {
+	cols := s.table.Generated()
+	values := make([]any, len(cols))
+	src := pgx.CopyFromSlice(len(rows), func(i int) ([]any, error) {
+		for j, c := range cols {
+			values[j] = copyValue(c, rows[i][j])
+		}
+		return values, nil
+	})
+	if _, err := s.conn.CopyFrom(ctx, pgx.Identifier{s.table.Name}, s.columns, src); err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	return nil
+}
+
+func (s *postgresCopySink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close(context.Background())
+}
+
+// copyValue adapts a canonical value for pgx's binary COPY encoding. pgx
+// handles uuids and addresses natively; JSON goes over as encoded text.
+func copyValue(c *dataset.Column, v any) any {
+	if c.Type.Kind == dataset.JSON && v != nil {
+		return dataset.FormatValue(c, v)
+	}
+	return v
+}
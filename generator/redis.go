@@ -2,109 +2,97 @@ package generator
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
 	"fmt"
-	"log"
-	mathRand "math/rand"
-	"time"
+
+	"datagenerator/config"
+	"datagenerator/generator/dataset"
 
 	"github.com/redis/go-redis/v9"
 )
 
-var globalClient *redis.Client
+type redisSink struct {
+	conn   config.Connection
+	table  *dataset.Table
+	client *redis.Client
+}
+
+// NewRedisSink writes table rows as <table>:<id> hashes, with IDs drawn from
+// a <table>:counter key
+func NewRedisSink(conn config.Connection, table *dataset.Table) Sink {
+	return &redisSink{conn: conn, table: table}
+}
 
-func Redis() {
-	username := "default"
-	password := "redisdb"
-	host := "localhost"
-	port := 6379
-	databaseIndex := 0
+func (s *redisSink) Name() string { return "Redis" }
+
+func (s *redisSink) Open(ctx context.Context) error {
+	databaseIndex, err := s.conn.RedisDB()
+	if err != nil {
+		return err
+	}
 
 	// Create Redis client
 	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", host, port),
-		Username: username,
-		Password: password,
+		Addr:     s.conn.Addr(),
+		Username: s.conn.User,
+		Password: s.conn.Password,
 		DB:       databaseIndex,
 	})
 
 	// Test the connection
-	ctx := context.Background()
-	_, err := client.Ping(ctx).Result()
-	if err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+	if _, err := client.Ping(ctx).Result(); err != nil {
+		client.Close()
+		return fmt.Errorf("failed to connect to Redis: %w", err)
 	}
-
-	globalClient = client
-	insertRedis()
+	s.client = client
+	return nil
 }
 
-func insertRedis() {
-	ctx := context.Background()
+// EnsureSchema is a no-op: hashes need no schema
+func (s *redisSink) EnsureSchema(context.Context) error {
+	return nil
+}
 
-	// Get current record count
-	currentCount, err := globalClient.Get(ctx, "user_activity:counter").Int64()
-	if err != nil && err.Error() != "redis: nil" {
-		currentCount = 0
+func (s *redisSink) WriteBatch(ctx context.Context, rows []dataset.Row) error {
+	// Reserve a block of record IDs up front so concurrent runs never overlap
+	lastID, err := s.client.IncrBy(ctx, s.table.Name+":counter", int64(len(rows))).Result()
+	if err != nil {
+		return fmt.Errorf("reserve ids: %w", err)
 	}
-
-	totalRecords := 1000000 // Insert 1 million records
-	batchSize := 1000
-
-	for i := 0; i < totalRecords; i += batchSize {
-		pipe := globalClient.Pipeline()
-
-		// Insert batch of records
-		for j := 0; j < batchSize && i+j < totalRecords; j++ {
-			recordID := currentCount + int64(i+j+1)
-			userActivity := generateUserActivity(recordID)
-
-			hashKey := fmt.Sprintf("user_activity:%d", recordID)
-			pipe.HMSet(ctx, hashKey, userActivity)
-		}
-
-		_, err := pipe.Exec(ctx)
-		if err != nil {
-			log.Fatalf("Pipeline execution failed: %v", err)
-		}
-
-		if (i+batchSize)%50000 == 0 || i+batchSize >= totalRecords {
-			fmt.Printf("Inserted %d records\n", i+batchSize)
-		}
+	firstID := lastID - int64(len(rows)) + 1
+
+	cols := s.table.Generated()
+	pipe := s.client.Pipeline()
+	for i, row := range rows {
+		recordID := firstID + int64(i)
+		hashKey := fmt.Sprintf("%s:%d", s.table.Name, recordID)
+		pipe.HSet(ctx, hashKey, redisHash(recordID, cols, row))
 	}
 
-	// Update counter
-	newCount := currentCount + int64(totalRecords)
-	globalClient.Set(ctx, "user_activity:counter", newCount, 0)
-
-	fmt.Printf("Completed: 1 million records inserted (Total: %d)\n", newCount)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("pipeline execution failed: %w", err)
+	}
+	return nil
 }
 
-func generateUserActivity(recordID int64) map[string]interface{} {
-	// Generate random session ID
-	sessionBytes := make([]byte, 16)
-	rand.Read(sessionBytes)
-	sessionID := hex.EncodeToString(sessionBytes)
-
-	// Generate timestamp within last 24 hours
-	timestamp := time.Now().Add(-time.Duration(mathRand.Intn(86400)) * time.Second)
+func (s *redisSink) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
 
-	return map[string]interface{}{
-		"id":                recordID,
-		"user_id":           mathRand.Int63n(100000),
-		"session_id":        sessionID,
-		"event_type":        mathRand.Intn(5) + 1,
-		"timestamp_utc":     timestamp.Unix(),
-		"partition_date":    timestamp.Format("2006-01-02"),
-		"ip_address":        fmt.Sprintf("192.168.%d.%d", mathRand.Intn(255), mathRand.Intn(255)),
-		"user_agent_hash":   mathRand.Int63(),
-		"page_url_hash":     mathRand.Int63(),
-		"referrer_hash":     mathRand.Int63(),
-		"country_code":      "US",
-		"device_type":       mathRand.Intn(3) + 1,
-		"response_time_ms":  mathRand.Intn(5000),
-		"status_code":       200,
-		"bytes_transferred": mathRand.Intn(10000),
+// redisHash stores numbers as-is and everything else in FormatValue text form
+func redisHash(recordID int64, cols []*dataset.Column, row dataset.Row) map[string]interface{} {
+	hash := make(map[string]interface{}, len(cols)+1)
+	hash["id"] = recordID
+	for i, c := range cols {
+		switch v := row[i].(type) {
+		case nil:
+		case int64, float64:
+			hash[c.Name] = v
+		default:
+			hash[c.Name] = dataset.FormatValue(c, v)
+		}
 	}
+	return hash
 }
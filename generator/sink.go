@@ -0,0 +1,283 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"datagenerator/generator/dataset"
+	"datagenerator/generator/pool"
+	"datagenerator/generator/rng"
+	"datagenerator/generator/workload"
+)
+
+// Sink is a destination for one dataset table. A run calls Open,
+// EnsureSchema, WriteBatch until the target row count is reached, then Close.
+// Parallel runs open one sink per writer and call EnsureSchema on the first
+// only; a sink is never used from two goroutines at once.
+type Sink interface {
+	// Name is the human readable backend name used in progress output
+	Name() string
+	Open(ctx context.Context) error
+	EnsureSchema(ctx context.Context) error
+	// WriteBatch writes rows holding one value per Table.Generated() column
+	WriteBatch(ctx context.Context, rows []dataset.Row) error
+	Close() error
+}
+
+// Key is the primary key of a row, one value per Table.PrimaryKey column
+// as scanned from the backend
+type Key []any
+
+// Mutator is implemented by sinks that can replay update and delete traffic
+// against rows that already exist
+type Mutator interface {
+	// LoadKeys returns the keys of up to limit of the newest rows
+	LoadKeys(ctx context.Context, limit int) ([]Key, error)
+	// UpdateBatch rewrites the mutable columns of the row at keys[i] with
+	// the values in rows[i]
+	UpdateBatch(ctx context.Context, keys []Key, rows []dataset.Row) error
+	DeleteBatch(ctx context.Context, keys []Key) error
+}
+
+// Partitioner is implemented by sinks that create range partitions on
+// demand, so rows dated outside the spec's partitions still have a home
+type Partitioner interface {
+	// EnsurePartitions adds the partitions needed for rows dated from
+	// through to
+	EnsurePartitions(ctx context.Context, from, to time.Time) error
+}
+
+// KeyPoolSize caps how many existing keys a mutation run loads
+const KeyPoolSize = 100000
+
+// runSink drives opts.Writers sinks, each with its own connection, from
+// opts.Generators row generators. Generator g produces its share of
+// opts.Records from its own fork of the seed and its own slice of IDs, or
+// with a stream profile, its share of the paced rate until the run stops.
+func runSink(ctx context.Context, newSink func() Sink, opts ActivityOptions) error {
+	streaming := opts.Stream.Enabled()
+	shares := rng.SplitIDs(int64(opts.Records), opts.Generators)
+	if streaming {
+		// Streams have no row count; give each generator an open-ended block
+		for g := range shares {
+			shares[g] = rng.IDRange{Next: int64(g) << 40, End: int64(g+1) << 40}
+		}
+	}
+
+	root := rng.New(opts.Seed, opts.Now)
+	gens := make([]*dataset.RowGenerator, opts.Generators)
+	for g := range gens {
+		// A single generator keeps the root stream so serial runs are unchanged
+		src := root
+		if opts.Generators > 1 {
+			src = root.Fork(int64(g))
+		}
+		src.IDs = shares[g]
+
+		var err error
+		if gens[g], err = dataset.NewRowGenerator(opts.Table, src); err != nil {
+			return err
+		}
+	}
+
+	sinks := make([]Sink, opts.Writers)
+	for w := range sinks {
+		sink := newSink()
+		if err := sink.Open(ctx); err != nil {
+			return fmt.Errorf("%s: open: %w", sink.Name(), err)
+		}
+		defer sink.Close()
+		sinks[w] = sink
+	}
+	name := sinks[0].Name()
+
+	if err := sinks[0].EnsureSchema(ctx); err != nil {
+		return fmt.Errorf("%s: ensure schema: %w", name, err)
+	}
+	if p, ok := sinks[0].(Partitioner); ok && opts.Table.Partition != nil {
+		if from, to, ok := opts.Table.TimeWindow(opts.Table.Partition.Column, opts.Now); ok {
+			if err := p.EnsurePartitions(ctx, from, to); err != nil {
+				return fmt.Errorf("%s: ensure partitions: %w", name, err)
+			}
+		}
+	}
+
+	mutating := opts.Mix.Mutates()
+	keys := &workload.KeyPool[Key]{}
+	if mutating {
+		mutator, ok := sinks[0].(Mutator)
+		if !ok {
+			return fmt.Errorf("%s does not support update and delete workloads", name)
+		}
+		loaded, err := mutator.LoadKeys(ctx, KeyPoolSize)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		keys.Add(loaded...)
+		fmt.Printf("Loaded %d existing keys for updates and deletes (mix %s)\n", len(loaded), &opts.Mix)
+	}
+
+	if streaming {
+		limit := "until interrupted"
+		if opts.Stream.Duration > 0 {
+			limit = "for " + opts.Stream.Duration.String()
+		}
+		fmt.Printf("Streaming %.0f records/second into %s %s...\n", opts.Stream.Rate, name, limit)
+	} else {
+		fmt.Printf("Starting insertion of %d records into %s in batches of %d...\n",
+			opts.Records, name, opts.BatchSize)
+	}
+	if opts.Generators > 1 || opts.Writers > 1 {
+		fmt.Printf("Using %d generators and %d writers\n", opts.Generators, opts.Writers)
+	}
+
+	start := time.Now()
+	var inserted atomic.Int64
+
+	// Streaming generators stop when stop ends; writers keep ctx so batches
+	// already generated are still written
+	stop := ctx
+	var pace *pacer
+	if streaming {
+		var cancel context.CancelFunc
+		stop, cancel = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+		if opts.Stream.Duration > 0 {
+			stop, cancel = context.WithTimeout(stop, opts.Stream.Duration)
+			defer cancel()
+		}
+		pace = newPacer(opts.Stream)
+		go reportStream(stop, pace, opts.Stream.Rate, &inserted)
+	}
+
+	produce := func(ctx context.Context, g int, out chan<- []dataset.Row) error {
+		if streaming {
+			n := opts.Stream.streamBatchSize(opts.BatchSize)
+			for pace.Wait(stop, n) {
+				if err := pool.Send(ctx, out, gens[g].Batch(nil, n)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for remaining := int(shares[g].End - shares[g].Next); remaining > 0; {
+			n := min(opts.BatchSize, remaining)
+			if err := pool.Send(ctx, out, gens[g].Batch(nil, n)); err != nil {
+				return err
+			}
+			remaining -= n
+		}
+		return nil
+	}
+	var counts [3]atomic.Int64
+	consume := func(ctx context.Context, w int, in <-chan []dataset.Row) error {
+		// Writers draw operations from forks numbered below the generators'
+		src := root.Fork(-1 - int64(w))
+		for batch := range in {
+			var err error
+			if mutating {
+				err = applyMix(ctx, sinks[w], keys, opts.Mix, src, batch, &counts)
+			} else {
+				err = sinks[w].WriteBatch(ctx, batch)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: write batch at record %d: %w", name, inserted.Load(), err)
+			}
+
+			total := inserted.Add(int64(len(batch)))
+			previous := total - int64(len(batch))
+			if !streaming && (total/50000 != previous/50000 || total == int64(opts.Records)) {
+				fmt.Printf("Inserted %d records\n", total)
+			}
+		}
+		return nil
+	}
+	if err := pool.Run(ctx, opts.Generators, opts.Writers, 2*opts.Writers, produce, consume); err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	fmt.Printf("✅ Completed: %d records inserted into %s %s in %s (%.0f records/second)\n",
+		inserted.Load(), name, opts.Table.Name, elapsed.Round(time.Millisecond), float64(inserted.Load())/elapsed.Seconds())
+	if mutating {
+		fmt.Printf("Operations: %d inserts, %d updates, %d deletes\n",
+			counts[workload.Insert].Load(), counts[workload.Update].Load(), counts[workload.Delete].Load())
+	}
+	if streaming {
+		target := pace.Target()
+		fmt.Printf("Target rate %.0f records/second, achieved %.0f (%.1f%% of the profile)\n",
+			target/elapsed.Seconds(), float64(inserted.Load())/elapsed.Seconds(), 100*float64(inserted.Load())/target)
+	}
+	return nil
+}
+
+// applyMix turns each generated row into an insert, or into an update or
+// delete of a pooled key as the mix dictates. Rows fall back to inserts
+// once the pool is empty.
+func applyMix(ctx context.Context, sink Sink, keys *workload.KeyPool[Key], mix workload.Mix,
+	src *rng.Source, batch []dataset.Row, counts *[3]atomic.Int64) error {
+	var inserts, updates []dataset.Row
+	var updateKeys, deleteKeys []Key
+	for _, row := range batch {
+		switch mix.Pick(src) {
+		case workload.Update:
+			if key, ok := keys.Pick(src); ok {
+				updateKeys, updates = append(updateKeys, key), append(updates, row)
+				continue
+			}
+		case workload.Delete:
+			if key, ok := keys.Take(src); ok {
+				deleteKeys = append(deleteKeys, key)
+				continue
+			}
+		}
+		inserts = append(inserts, row)
+	}
+
+	mutator := sink.(Mutator)
+	if len(inserts) > 0 {
+		if err := sink.WriteBatch(ctx, inserts); err != nil {
+			return err
+		}
+	}
+	if err := mutator.UpdateBatch(ctx, updateKeys, updates); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	if err := mutator.DeleteBatch(ctx, deleteKeys); err != nil {
+		return fmt.Errorf("delete: %w", err)
+	}
+	counts[workload.Insert].Add(int64(len(inserts)))
+	counts[workload.Update].Add(int64(len(updateKeys)))
+	counts[workload.Delete].Add(int64(len(deleteKeys)))
+	return nil
+}
+
+// streamReportInterval is how often a streaming run prints its rates
+const streamReportInterval = 10 * time.Second
+
+// reportStream prints the achieved and target rate of the last interval
+// until stop ends
+func reportStream(stop context.Context, pace *pacer, rate float64, inserted *atomic.Int64) {
+	ticker := time.NewTicker(streamReportInterval)
+	defer ticker.Stop()
+
+	var lastRows int64
+	var lastTarget float64
+	for {
+		select {
+		case <-stop.Done():
+			return
+		case <-ticker.C:
+		}
+		rows, target := inserted.Load(), pace.Target()
+		seconds := streamReportInterval.Seconds()
+		fmt.Printf("Inserted %d records: %.0f records/second (target %.0f, steady state %.0f)\n",
+			rows, float64(rows-lastRows)/seconds, (target-lastTarget)/seconds, rate)
+		lastRows, lastTarget = rows, target
+	}
+}
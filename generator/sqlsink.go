@@ -0,0 +1,178 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"datagenerator/generator/dataset"
+)
+
+// sqlSink writes a dataset table through database/sql, rendering DDL and
+// inserts with the backend's dialect
+type sqlSink struct {
+	name    string
+	driver  string
+	dsn     string
+	dialect dataset.Dialect
+	table   *dataset.Table
+	db      *sql.DB
+}
+
+func (s *sqlSink) Name() string { return s.name }
+
+func (s *sqlSink) Open(ctx context.Context) error {
+	db, err := sql.Open(s.driver, s.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open connection: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	s.db = db
+	return nil
+}
+
+// EnsureSchema runs the dialect's idempotent DDL for the table
+func (s *sqlSink) EnsureSchema(ctx context.Context) error {
+	for _, stmt := range s.dialect.CreateTable(s.table) {
+		if _, err := s.db.ExecContext(ctx, stmt.SQL); err != nil {
+			return fmt.Errorf("failed creating %s %s: %w", stmt.Object, stmt.Name, err)
+		}
+	}
+	return nil
+}
+
+// EnsurePartitions lists the table's partitions and runs the dialect's
+// statements adding the missing ones
+func (s *sqlSink) EnsurePartitions(ctx context.Context, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, s.dialect.ListPartitions(s.table))
+	if err != nil {
+		return fmt.Errorf("failed listing partitions: %w", err)
+	}
+	defer rows.Close()
+	var existing []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		existing = append(existing, name)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, stmt := range s.dialect.AddPartitions(s.table, existing, from, to) {
+		if _, err := s.db.ExecContext(ctx, stmt.SQL); err != nil {
+			return fmt.Errorf("failed adding partition %s: %w", stmt.Name, err)
+		}
+		fmt.Printf("Partition %s added to %s\n", stmt.Name, s.table.Name)
+	}
+	return nil
+}
+
+func (s *sqlSink) WriteBatch(ctx context.Context, rows []dataset.Row) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, s.dialect.Insert(s.table))
+	if err != nil {
+		return fmt.Errorf("prepare failed: %w", err)
+	}
+	defer stmt.Close()
+
+	cols := s.table.Generated()
+	args := make([]any, len(cols))
+	for _, row := range rows {
+		for i, c := range cols {
+			args[i] = s.dialect.Bind(c, row[i])
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqlSink) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *sqlSink) LoadKeys(ctx context.Context, limit int) ([]Key, error) {
+	rows, err := s.db.QueryContext(ctx, s.dialect.SelectKeys(s.table, limit))
+	if err != nil {
+		return nil, fmt.Errorf("loading keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		key := make(Key, len(s.table.PrimaryKey))
+		dest := make([]any, len(key))
+		for i := range key {
+			dest[i] = &key[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *sqlSink) UpdateBatch(ctx context.Context, keys []Key, rows []dataset.Row) error {
+	var positions []int
+	var mutable []*dataset.Column
+	for i, c := range s.table.Generated() {
+		if c.Mutable {
+			positions = append(positions, i)
+			mutable = append(mutable, c)
+		}
+	}
+	return s.execEach(ctx, s.dialect.Update(s.table), len(keys), func(n int) []any {
+		args := make([]any, 0, len(mutable)+len(keys[n]))
+		for i, c := range mutable {
+			args = append(args, s.dialect.Bind(c, rows[n][positions[i]]))
+		}
+		return append(args, keys[n]...)
+	})
+}
+
+func (s *sqlSink) DeleteBatch(ctx context.Context, keys []Key) error {
+	return s.execEach(ctx, s.dialect.Delete(s.table), len(keys), func(n int) []any { return keys[n] })
+}
+
+// execEach runs query count times in one transaction with args(n) as the
+// arguments of the n-th execution
+func (s *sqlSink) execEach(ctx context.Context, query string, count int, args func(n int) []any) error {
+	if count == 0 {
+		return nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("prepare failed: %w", err)
+	}
+	defer stmt.Close()
+
+	for n := range count {
+		if _, err := stmt.ExecContext(ctx, args(n)...); err != nil {
+			return fmt.Errorf("exec failed: %w", err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// StreamProfile describes the row rate of a streaming run. A zero Rate
+// means the run is a one-shot load of ActivityOptions.Records rows.
+type StreamProfile struct {
+	Rate        float64       // Steady-state rows per second
+	Duration    time.Duration // How long to stream; zero streams until SIGINT
+	RampUp      time.Duration // Linear ramp from 0 to Rate at the start
+	BurstEvery  time.Duration // Interval between bursts; zero disables bursts
+	BurstFor    time.Duration // Length of each burst
+	BurstFactor float64       // Rate multiplier during a burst
+}
+
+// Enabled reports whether the run streams instead of loading a fixed count
+func (p StreamProfile) Enabled() bool { return p.Rate > 0 }
+
+func (p StreamProfile) validate() error {
+	if p.Rate < 0 || p.Duration < 0 || p.RampUp < 0 || p.BurstEvery < 0 || p.BurstFor < 0 {
+		return fmt.Errorf("stream rate and durations must not be negative")
+	}
+	if p.BurstEvery > 0 && (p.BurstFor <= 0 || p.BurstFor >= p.BurstEvery || p.BurstFactor <= 0) {
+		return fmt.Errorf("bursts need 0 < burst-for < burst-every and a positive burst-factor")
+	}
+	return nil
+}
+
+// RateAt returns the target rows per second at elapsed time into the run
+func (p StreamProfile) RateAt(elapsed time.Duration) float64 {
+	rate := p.Rate
+	if elapsed < p.RampUp {
+		rate *= float64(elapsed) / float64(p.RampUp)
+	}
+	if p.BurstEvery > 0 && elapsed%p.BurstEvery < p.BurstFor {
+		rate *= p.BurstFactor
+	}
+	return rate
+}
+
+// streamBatchSize keeps batches small enough for about ten writes a second,
+// so pacing stays smooth at low rates
+func (p StreamProfile) streamBatchSize(batchSize int) int {
+	return max(1, min(batchSize, int(p.Rate*max(1, p.BurstFactor)/10)))
+}
+
+// pacingStep is the resolution used to integrate the rate profile
+const pacingStep = 10 * time.Millisecond
+
+// pacer hands out row quotas to generator goroutines so that the total
+// follows the integral of a StreamProfile
+type pacer struct {
+	profile StreamProfile
+	start   time.Time
+
+	mu       sync.Mutex
+	granted  float64       // rows handed out so far
+	allowed  float64       // rows the profile allows up to integrated
+	integral time.Duration // elapsed time allowed is computed for
+}
+
+func newPacer(profile StreamProfile) *pacer {
+	return &pacer{profile: profile, start: time.Now()}
+}
+
+// advance integrates the profile up to elapsed. p.mu must be held.
+func (p *pacer) advance(elapsed time.Duration) {
+	for p.integral < elapsed {
+		step := min(pacingStep, elapsed-p.integral)
+		p.allowed += p.profile.RateAt(p.integral+step/2) * step.Seconds()
+		p.integral += step
+	}
+}
+
+// Target returns how many rows the profile called for by now
+func (p *pacer) Target() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.advance(time.Since(p.start))
+	return p.allowed
+}
+
+// Wait reserves n rows and blocks until the profile allows them. It reports
+// false if stop ends first.
+func (p *pacer) Wait(stop context.Context, n int) bool {
+	p.mu.Lock()
+	p.granted += float64(n)
+	due := p.granted
+	p.mu.Unlock()
+
+	for {
+		p.mu.Lock()
+		elapsed := time.Since(p.start)
+		p.advance(elapsed)
+		deficit := due - p.allowed
+		rate := p.profile.RateAt(elapsed)
+		p.mu.Unlock()
+
+		if deficit <= 0 {
+			return true
+		}
+		wait := 100 * time.Millisecond
+		if rate > 0 {
+			wait = min(wait, time.Duration(deficit/rate*float64(time.Second)))
+		}
+		select {
+		case <-stop.Done():
+			return false
+		case <-time.After(max(wait, time.Millisecond)):
+		}
+	}
+}
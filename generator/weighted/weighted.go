@@ -0,0 +1,198 @@
+// Package weighted draws values from weighted categorical distributions in
+// constant time with Vose's alias method, optionally conditioned on another
+// value, with weights that can come from YAML config.
+package weighted
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"datagenerator/generator/rng"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Alias samples indexes 0..n-1 in proportion to their weights with one
+// uniform index and one biased coin per draw
+type Alias struct {
+	prob  []float64
+	alias []int
+}
+
+// NewAlias builds the alias table for weights, which must be finite,
+// non-negative and not all zero
+func NewAlias(weights []float64) (*Alias, error) {
+	n := len(weights)
+	if n == 0 {
+		return nil, fmt.Errorf("no weights")
+	}
+	total := 0.0
+	for _, w := range weights {
+		if math.IsNaN(w) || math.IsInf(w, 0) {
+			return nil, fmt.Errorf("non-finite weight %v", w)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("negative weight %v", w)
+		}
+		total += w
+	}
+	if math.IsInf(total, 0) {
+		return nil, fmt.Errorf("weights overflow")
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("weights sum to zero")
+	}
+
+	a := &Alias{prob: make([]float64, n), alias: make([]int, n)}
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, w := range weights {
+		scaled[i] = w * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+	for len(small) > 0 && len(large) > 0 {
+		s, l := small[len(small)-1], large[len(large)-1]
+		small = small[:len(small)-1]
+		a.prob[s], a.alias[s] = scaled[s], l
+		scaled[l] += scaled[s] - 1
+		if scaled[l] < 1 {
+			large = large[:len(large)-1]
+			small = append(small, l)
+		}
+	}
+	// Whatever is left is 1 up to rounding
+	for _, i := range append(small, large...) {
+		a.prob[i], a.alias[i] = 1, i
+	}
+	return a, nil
+}
+
+// Pick draws an index
+func (a *Alias) Pick(src *rng.Source) int {
+	i := src.Intn(len(a.prob))
+	if src.Float64() < a.prob[i] {
+		return i
+	}
+	return a.alias[i]
+}
+
+// Choice is a weighted choice over values. It is read-only after New, so one
+// Choice can serve every goroutine.
+type Choice[T any] struct {
+	values []T
+	alias  *Alias // nil for equal weights
+}
+
+// New builds a choice over values. Empty weights make every value equally
+// likely; otherwise there must be one weight per value.
+func New[T any](values []T, weights []float64) (*Choice[T], error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("values is empty")
+	}
+	c := &Choice[T]{values: values}
+	if len(weights) == 0 {
+		return c, nil
+	}
+	if len(weights) != len(values) {
+		return nil, fmt.Errorf("%d weights for %d values", len(weights), len(values))
+	}
+	var err error
+	c.alias, err = NewAlias(weights)
+	return c, err
+}
+
+// Pick draws a value
+func (c *Choice[T]) Pick(src *rng.Source) T {
+	if c.alias == nil {
+		return c.values[src.Intn(len(c.values))]
+	}
+	return c.values[c.alias.Pick(src)]
+}
+
+// Values lists the values in the order they were given
+func (c *Choice[T]) Values() []T {
+	return c.values
+}
+
+// Conditional picks among the same values with weights that depend on a
+// key, such as status weights per event type. Keys without weights of
+// their own use the default weights.
+type Conditional[K comparable, T any] struct {
+	def   *Choice[T]
+	given map[K]*Choice[T]
+}
+
+// NewConditional builds a choice over values weighted by weights, or by
+// given[key] when the key has an entry
+func NewConditional[K comparable, T any](values []T, weights []float64, given map[K][]float64) (*Conditional[K, T], error) {
+	def, err := New(values, weights)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conditional[K, T]{def: def, given: make(map[K]*Choice[T], len(given))}
+	for key, w := range given {
+		if c.given[key], err = New(values, w); err != nil {
+			return nil, fmt.Errorf("given %v: %w", key, err)
+		}
+	}
+	return c, nil
+}
+
+// Pick draws a value for key
+func (c *Conditional[K, T]) Pick(src *rng.Source, key K) T {
+	if choice, ok := c.given[key]; ok {
+		return choice.Pick(src)
+	}
+	return c.def.Pick(src)
+}
+
+// Spec is a weighted choice as written in YAML:
+//
+//	values: [completed, pending, failed]
+//	weights: [90, 5, 5]
+//	given:              # optional weights per value of another field
+//	  refund: [60, 35, 5]
+type Spec struct {
+	Values  []string             `yaml:"values"`
+	Weights []float64            `yaml:"weights"`
+	Given   map[string][]float64 `yaml:"given"`
+}
+
+// Choice builds the unconditional choice described by s
+func (s Spec) Choice() (*Choice[string], error) {
+	if len(s.Given) > 0 {
+		return nil, fmt.Errorf("given weights need a conditional choice")
+	}
+	return New(s.Values, s.Weights)
+}
+
+// Conditional builds the choice described by s, keyed by the given values
+func (s Spec) Conditional() (*Conditional[string, string], error) {
+	return NewConditional(s.Values, s.Weights, s.Given)
+}
+
+// Specs is a named set of choices, the layout of a weights file
+type Specs map[string]Spec
+
+// ParseSpecs decodes a weights document
+func ParseSpecs(data []byte) (Specs, error) {
+	var specs Specs
+	if err := yaml.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("invalid weights: %w", err)
+	}
+	return specs, nil
+}
+
+// LoadSpecs reads a weights file
+func LoadSpecs(path string) (Specs, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpecs(data)
+}
@@ -0,0 +1,66 @@
+package weighted
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"datagenerator/generator/rng"
+)
+
+func TestNewAliasErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+	}{
+		{"empty", nil},
+		{"negative", []float64{1, -1}},
+		{"all zero", []float64{0, 0}},
+		{"NaN", []float64{1, math.NaN()}},
+		{"+Inf", []float64{1, math.Inf(1)}},
+		{"-Inf", []float64{math.Inf(-1), 1}},
+		{"overflow", []float64{math.MaxFloat64, math.MaxFloat64}},
+	}
+	for _, tt := range tests {
+		if _, err := NewAlias(tt.weights); err == nil {
+			t.Errorf("%s: NewAlias(%v) succeeded, want an error", tt.name, tt.weights)
+		}
+	}
+}
+
+func TestAliasFrequencies(t *testing.T) {
+	tests := [][]float64{
+		{1},
+		{1, 1, 1, 1},
+		{1, 2, 3, 0, 4},
+		{0.001, 1000},
+		{50, 20, 10, 10, 5, 3, 1, 1},
+	}
+	const draws = 200000
+	src := rng.New(1, time.Time{})
+	for _, weights := range tests {
+		a, err := NewAlias(weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts := make([]int, len(weights))
+		for range draws {
+			counts[a.Pick(src)]++
+		}
+		total := 0.0
+		for _, w := range weights {
+			total += w
+		}
+		for i, w := range weights {
+			p := w / total
+			got := float64(counts[i]) / draws
+			// Five standard deviations of the binomial proportion
+			if tol := 5 * math.Sqrt(p*(1-p)/draws); math.Abs(got-p) > tol {
+				t.Errorf("weights %v: index %d drawn %.4f of the time, want %.4f ± %.4f", weights, i, got, p, tol)
+			}
+			if w == 0 && counts[i] > 0 {
+				t.Errorf("weights %v: zero-weight index %d drawn %d times", weights, i, counts[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package workload describes change-data-capture style traffic: a mix of
+// inserts, updates and deletes aimed at keys drawn from a pool of rows
+// known to exist.
+package workload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"datagenerator/generator/rng"
+)
+
+// Op is one kind of row change
+type Op int
+
+const (
+	Insert Op = iota
+	Update
+	Delete
+)
+
+func (o Op) String() string {
+	return [...]string{"insert", "update", "delete"}[o]
+}
+
+// Mix is the relative weight of each Op, written insert/update/delete as
+// in 70/25/5. It implements flag.Value.
+type Mix struct {
+	Insert, Update, Delete int
+}
+
+// InsertOnly is the mix of a plain load
+var InsertOnly = Mix{Insert: 100}
+
+// ParseMix parses "insert/update/delete" weights
+func ParseMix(s string) (Mix, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return Mix{}, fmt.Errorf("mix must look like insert/update/delete, e.g. 70/25/5, got %q", s)
+	}
+	var weights [3]int
+	for i, part := range parts {
+		w, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || w < 0 {
+			return Mix{}, fmt.Errorf("mix weights must be non-negative integers, got %q", s)
+		}
+		weights[i] = w
+	}
+	m := Mix{Insert: weights[0], Update: weights[1], Delete: weights[2]}
+	if m.total() == 0 {
+		return Mix{}, fmt.Errorf("mix weights sum to zero")
+	}
+	return m, nil
+}
+
+func (m *Mix) String() string {
+	return fmt.Sprintf("%d/%d/%d", m.Insert, m.Update, m.Delete)
+}
+
+func (m *Mix) Set(s string) error {
+	parsed, err := ParseMix(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+func (m Mix) total() int { return m.Insert + m.Update + m.Delete }
+
+// Mutates reports whether the mix includes updates or deletes
+func (m Mix) Mutates() bool { return m.Update > 0 || m.Delete > 0 }
+
+// Pick draws an Op with the mix's weights
+func (m Mix) Pick(src *rng.Source) Op {
+	n := src.Intn(m.total())
+	switch {
+	case n < m.Insert:
+		return Insert
+	case n < m.Insert+m.Update:
+		return Update
+	default:
+		return Delete
+	}
+}
+
+// Counts tallies operations by Op
+type Counts [3]int64
+
+func (c Counts) String() string {
+	return fmt.Sprintf("%d inserts, %d updates, %d deletes", c[Insert], c[Update], c[Delete])
+}
+
+// KeyPool holds keys of rows known to exist. Updates pick a key and leave
+// it in the pool; deletes take it out. It is safe for concurrent use.
+type KeyPool[K any] struct {
+	mu   sync.Mutex
+	keys []K
+}
+
+// Add puts keys into the pool
+func (p *KeyPool[K]) Add(keys ...K) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys = append(p.keys, keys...)
+}
+
+// Len returns the number of keys in the pool
+func (p *KeyPool[K]) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.keys)
+}
+
+// Pick returns a random key, or false if the pool is empty
+func (p *KeyPool[K]) Pick(src *rng.Source) (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var zero K
+	if len(p.keys) == 0 {
+		return zero, false
+	}
+	return p.keys[src.Intn(len(p.keys))], true
+}
+
+// Take removes and returns a random key, or false if the pool is empty
+func (p *KeyPool[K]) Take(src *rng.Source) (K, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var zero K
+	if len(p.keys) == 0 {
+		return zero, false
+	}
+	i := src.Intn(len(p.keys))
+	key := p.keys[i]
+	last := len(p.keys) - 1
+	p.keys[i] = p.keys[last]
+	p.keys = p.keys[:last]
+	return key, true
+}
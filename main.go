@@ -1,26 +1,47 @@
 package main
 
 import (
-	generator "datagenerator/generator/postgres"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
 )
 
 func main() {
-	// generator.MariaDB()
-	// generator.MySQL()
-	// generator.Postgres()
-	// generator.MSSQL()
-	// generator.Oracle()
-	// generator.Redis()
-	// generator.MongoDB()
-	// generator.MySQLRelational()
-	// generator.PostgresRelational()
-	// generator.OracleRelational()
-	// generator.MSQLRelational()
-	// generator.MSSQLECommerceOrderBroker()
-	// generator.Elasticsearch()
-	// for range 10 {
-	// 	generator.PerformSeed()
-	// }
-	// generator.CreateElasticsearchSchema()
-	generator.CreateAirportDemoPostgresSchema()
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: datagenerator <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'datagenerator <command> -h' for command flags.")
 }